@@ -16,68 +16,169 @@ import (
 	"strconv"
 	"strings"
 	"time"
-)
 
-// http client
-var httpClient *http.Client
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
 
 //byte pool
 //var bytePool sync.Pool
 
-func init() {
-	//http client
-	var (
-		connectTimeout = 60 * time.Second
-		headerTimeout  = 600 * time.Second
-		keepAlive      = 60 * time.Second
-	)
-	httpClient = &http.Client{
-		Transport: &http.Transport{
-			DialContext: (&net.Dialer{
-				//connectTimeout
-				Timeout: connectTimeout,
+//func init() {
+//	//byte pool
+//	bytePool = sync.Pool{
+//		New: func() any {
+//			buf := make([]byte, 32*1024)
+//			return &buf
+//		},
+//	}
+//}
+
+// Client http客户端
+type Client struct {
+	HTTPClient  *http.Client
+	ReadTimeout time.Duration
+	RetryPolicy s3.RetryPolicy
+	budget      *retryBudget
+}
+
+// NewClient 根据配置创建http客户端
+func NewClient(cfg *s3.Config) *Client {
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		transport := cfg.Transport
+		if transport == nil {
+			transport = &http.Transport{
+				DialContext: (&net.Dialer{
+					//connectTimeout
+					Timeout: cfg.ConnectTimeout,
+					//keepAlive
+					KeepAlive: cfg.KeepAlive,
+				}).DialContext,
+				MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
 				//keepAlive
-				KeepAlive: keepAlive,
-			}).DialContext,
-			MaxIdleConnsPerHost: 200,
-			//keepAlive
-			IdleConnTimeout: keepAlive,
-			//headerTimeout
-			ResponseHeaderTimeout: headerTimeout,
-		},
+				IdleConnTimeout: cfg.KeepAlive,
+				//headerTimeout
+				ResponseHeaderTimeout: cfg.HeaderTimeout,
+				TLSClientConfig:       cfg.TLSConfig,
+			}
+		}
+		httpClient = &http.Client{Transport: transport}
+	}
+	retryPolicy := cfg.RetryPolicy
+	if retryPolicy == nil {
+		retryPolicy = s3.NewRetryPolicy(cfg.MaxRetryNum)
 	}
+	return &Client{
+		HTTPClient:  httpClient,
+		ReadTimeout: cfg.ReadTimeout,
+		RetryPolicy: retryPolicy,
+		budget:      newRetryBudget(cfg.RetryBudget),
+	}
+}
 
-	//byte pool
-	//bytePool = sync.Pool{
-	//	New: func() any {
-	//		buf := make([]byte, 32*1024)
-	//		return &buf
-	//	},
-	//}
+// 单个请求的context,ReadTimeout为0时不限制
+func (c *Client) context(parent context.Context) (context.Context, context.CancelFunc) {
+	if parent == nil {
+		parent = context.Background()
+	}
+	if c.ReadTimeout > 0 {
+		return context.WithTimeout(parent, c.ReadTimeout)
+	}
+	return context.WithCancel(parent)
 }
 
-func CURL(addr, method string, headers map[string]string, body io.Reader, dsc io.Writer, exitChan <-chan bool) (http.Header, error) {
-	//readTimeout
-	var readTimeout = 600 * time.Second
-	ctx, cancel := context.WithTimeout(context.Background(), readTimeout)
-	defer cancel()
+// ExitContext 将退出通道转换为context,收到true或通道关闭前一直有效
+func ExitContext(exitChan <-chan bool) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
 	if exitChan != nil {
 		go func() {
 			for {
-				exit, ok := <-exitChan
-				if !ok {
-					break
-				}
-				if exit {
-					cancel()
-					break
+				select {
+				case exit, ok := <-exitChan:
+					if !ok {
+						return
+					}
+					if exit {
+						cancel()
+						return
+					}
+				case <-ctx.Done():
+					return
 				}
 			}
 		}()
 	}
-	req, err := http.NewRequest(method, addr, body)
+	return ctx, cancel
+}
+
+// CURL http请求,失败时按重试策略重试
+// body需实现io.Seeker才能重试,已写入dsc的内容仅在dsc为*os.File或*bytes.Buffer时回退重试
+// 状态码>=400时响应体不写入dsc,通过errBody返回
+func (c *Client) CURL(ctx context.Context, addr, method string, headers map[string]string, body io.Reader, dsc io.Writer) (http.Header, []byte, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	rewindBody := bodyRewinder(body)
+	rewindDsc := writerRewinder(dsc)
+	//重试占用的额度在请求结束时归还
+	var acquired int
+	defer func() {
+		c.budget.release(acquired)
+	}()
+	for attempt := 0; ; attempt++ {
+		resp, errBody, written, err := c.do(ctx, addr, method, headers, body, dsc)
+		failure := retryAttempt(attempt, resp, errBody, err)
+		if failure == nil {
+			resp.Header.Set("StatusCode", fmt.Sprintf("%d", resp.StatusCode))
+			return resp.Header, nil, nil
+		}
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		var delay time.Duration
+		var retry bool
+		if c.RetryPolicy != nil {
+			delay, retry = c.RetryPolicy.Retry(failure)
+		}
+		if retry && (rewindBody == nil || (written > 0 && rewindDsc == nil)) {
+			retry = false
+		}
+		if !retry || !c.budget.acquire() {
+			if err != nil {
+				return nil, nil, err
+			}
+			resp.Header.Set("StatusCode", fmt.Sprintf("%d", resp.StatusCode))
+			return resp.Header, errBody, nil
+		}
+		acquired++
+		if err = sleep(ctx, delay); err != nil {
+			return nil, nil, err
+		}
+		if err = rewindBody(); err != nil {
+			return nil, nil, err
+		}
+		if written > 0 {
+			if err = rewindDsc(); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+}
+
+// Header http header请求
+func (c *Client) Header(ctx context.Context, addr, method string, headers map[string]string) (http.Header, error) {
+	header, _, err := c.CURL(ctx, addr, method, headers, strings.NewReader(""), nil)
+	return header, err
+}
+
+// do 发送一次请求,状态码>=400时返回响应体用于判断错误码,否则将响应体写入dsc
+func (c *Client) do(ctx context.Context, addr, method string, headers map[string]string, body io.Reader, dsc io.Writer) (*http.Response, []byte, int64, error) {
+	//readTimeout
+	ctx, cancel := c.context(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, addr, body)
 	if err != nil {
-		return nil, err
+		return nil, nil, 0, err
 	}
 	for k, v := range headers {
 		if req.Header.Get(k) != "" {
@@ -90,9 +191,9 @@ func CURL(addr, method string, headers map[string]string, body io.Reader, dsc io
 	if cl > 0 {
 		req.ContentLength = cl
 	}
-	resp, err := httpClient.Do(req.WithContext(ctx))
+	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, 0, err
 	}
 	defer func() {
 		//buf := bytePool.Get().(*[]byte)
@@ -101,55 +202,28 @@ func CURL(addr, method string, headers map[string]string, body io.Reader, dsc io
 		_, _ = io.Copy(io.Discard, resp.Body)
 		resp.Body.Close()
 	}()
-	if dsc != nil {
-		//buf := bytePool.Get().(*[]byte)
-		//_, err = io.CopyBuffer(dsc, resp.Body, *buf)
-		//bytePool.Put(buf)
-		_, err = io.Copy(dsc, resp.Body)
+	if resp.StatusCode >= http.StatusBadRequest {
+		errBody, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
 		if err != nil {
-			return nil, err
+			return nil, nil, 0, err
 		}
+		return resp, errBody, 0, nil
 	}
-	resp.Header.Set("StatusCode", fmt.Sprintf("%d", resp.StatusCode))
-	return resp.Header, nil
-	//result := map[string]interface{}{
-	//	"StatusCode": resp.StatusCode,
-	//}
-	//for k, v := range resp.Header {
-	//	result[k] = v[0]
-	//}
-}
-
-// Header http header请求
-func Header(addr, method string, headers map[string]string) (http.Header, error) {
-	req, err := http.NewRequest(method, addr, strings.NewReader(""))
-	if err != nil {
-		return nil, err
-	}
-	for k, v := range headers {
-		if req.Header.Get(k) != "" {
-			req.Header.Set(k, v)
-		} else {
-			req.Header.Add(k, v)
-		}
+	if dsc == nil {
+		return resp, nil, 0, nil
 	}
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, err
+	if cw, ok := dsc.(*ChecksumWriter); ok {
+		cw.reset(resp.Header)
 	}
-	defer resp.Body.Close()
 	//buf := bytePool.Get().(*[]byte)
-	//_, _ = io.CopyBuffer(ioutil.Discard, resp.Body, *buf)
+	//_, err = io.CopyBuffer(dsc, resp.Body, *buf)
 	//bytePool.Put(buf)
-	_, _ = io.Copy(io.Discard, resp.Body)
-	resp.Header.Set("StatusCode", fmt.Sprintf("%d", resp.StatusCode))
-	return resp.Header, nil
-	//result := map[string]interface{}{
-	//	"StatusCode": resp.StatusCode,
-	//}
-	//for k, v := range resp.Header {
-	//	result[k] = v[0]
-	//}
+	cw := &countWriter{w: dsc}
+	_, err = io.Copy(cw, resp.Body)
+	if err != nil {
+		return nil, nil, cw.n, err
+	}
+	return resp, nil, cw.n, nil
 }
 
 func Base64Encode(data []byte) string {
@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
+func TestCURLErrorBody(t *testing.T) {
+	const errXML = `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(errXML))
+		case "/slow":
+			if requests == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write([]byte(`<Error><Code>SlowDown</Code></Error>`))
+				return
+			}
+			_, _ = w.Write([]byte("content"))
+		}
+	}))
+	defer srv.Close()
+	c := NewClient(&s3.Config{HTTPClient: srv.Client(), RetryPolicy: s3.NewRetryPolicy(3)})
+
+	dsc := &bytes.Buffer{}
+	header, errBody, err := c.CURL(context.Background(), srv.URL+"/missing", "GET", nil, strings.NewReader(""), dsc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("StatusCode") != "404" || string(errBody) != errXML {
+		t.Errorf("StatusCode = %s, errBody = %q", header.Get("StatusCode"), errBody)
+	}
+	if dsc.Len() != 0 {
+		t.Errorf("error body written to dsc: %q", dsc.String())
+	}
+	respErr := NewResponseError("Cat", "bucket", "missing", header, errBody)
+	if respErr.Code != "NoSuchKey" || !s3.IsNotFound(respErr) {
+		t.Errorf("NewResponseError = %+v", respErr)
+	}
+
+	requests = 0
+	dsc.Reset()
+	header, errBody, err = c.CURL(context.Background(), srv.URL+"/slow", "GET", nil, strings.NewReader(""), dsc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("StatusCode") != "200" || errBody != nil || dsc.String() != "content" || requests != 2 {
+		t.Errorf("StatusCode = %s, errBody = %q, dsc = %q, requests = %d", header.Get("StatusCode"), errBody, dsc.String(), requests)
+	}
+}
+
+// roundTripFunc 自定义http传输层
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestNewClient(t *testing.T) {
+	// 默认传输层使用配置的超时及连接数
+	c := NewClient(s3.NewConfig(
+		s3.WithConnectTimeout(time.Second),
+		s3.WithHeaderTimeout(2*time.Second),
+		s3.WithReadTimeout(3*time.Second),
+		s3.WithKeepAlive(4*time.Second),
+		s3.WithMaxIdleConnsPerHost(5),
+	))
+	transport, ok := c.HTTPClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Transport = %T, want *http.Transport", c.HTTPClient.Transport)
+	}
+	if transport.ResponseHeaderTimeout != 2*time.Second || transport.IdleConnTimeout != 4*time.Second || transport.MaxIdleConnsPerHost != 5 {
+		t.Errorf("Transport = %+v", transport)
+	}
+	if c.ReadTimeout != 3*time.Second {
+		t.Errorf("ReadTimeout = %s, want 3s", c.ReadTimeout)
+	}
+
+	// 自定义传输层
+	custom := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, http.ErrNotSupported
+	})
+	c = NewClient(s3.NewConfig(s3.WithTransport(custom), s3.WithReadTimeout(0)))
+	if _, ok = c.HTTPClient.Transport.(roundTripFunc); !ok {
+		t.Errorf("Transport = %T, want the custom transport", c.HTTPClient.Transport)
+	}
+	if c.ReadTimeout != 0 {
+		t.Errorf("ReadTimeout = %s, want 0", c.ReadTimeout)
+	}
+
+	// 自定义http客户端优先于传输层
+	httpClient := &http.Client{Timeout: time.Minute}
+	c = NewClient(s3.NewConfig(s3.WithTransport(custom), s3.WithHTTPClient(httpClient)))
+	if c.HTTPClient != httpClient {
+		t.Errorf("HTTPClient = %p, want %p", c.HTTPClient, httpClient)
+	}
+}
@@ -0,0 +1,209 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// UploadCheckpoint 分块上传的断点,记录在本地文件中
+// nil表示不使用断点,所有方法都可以在nil上调用
+type UploadCheckpoint struct {
+	path string
+	mu   sync.Mutex
+
+	Bucket   string
+	Object   string
+	FilePath string
+	FileSize int64
+	ModTime  time.Time
+	PartSize int
+	UploadID string
+	// Parts 已完成分块的ETag,下标为分块编号-1,未完成的为空
+	Parts []string
+}
+
+// LoadUploadCheckpoint 读取断点文件,path为空、文件不存在或无法解析时返回nil
+func LoadUploadCheckpoint(path string) *UploadCheckpoint {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	cp := &UploadCheckpoint{path: path}
+	if err = json.Unmarshal(data, cp); err != nil || cp.UploadID == "" || cp.PartSize <= 0 {
+		return nil
+	}
+	return cp
+}
+
+// NewUploadCheckpoint 创建断点,path为空时返回nil
+func NewUploadCheckpoint(path, bucket, object, filePath string, info os.FileInfo, partSize int, uploadID string) *UploadCheckpoint {
+	if path == "" {
+		return nil
+	}
+	total := (int(info.Size()) + partSize - 1) / partSize
+	return &UploadCheckpoint{
+		path:     path,
+		Bucket:   bucket,
+		Object:   object,
+		FilePath: filePath,
+		FileSize: info.Size(),
+		ModTime:  info.ModTime(),
+		PartSize: partSize,
+		UploadID: uploadID,
+		Parts:    make([]string, total),
+	}
+}
+
+// Valid 断点是否属于同一上传且本地文件的大小、修改时间未变化
+func (cp *UploadCheckpoint) Valid(bucket, object, filePath string, info os.FileInfo) bool {
+	if cp == nil {
+		return false
+	}
+	total := (int(info.Size()) + cp.PartSize - 1) / cp.PartSize
+	return cp.Bucket == bucket && cp.Object == object && cp.FilePath == filePath &&
+		cp.FileSize == info.Size() && cp.ModTime.Equal(info.ModTime()) && len(cp.Parts) == total
+}
+
+// Done 记录完成的分块并写入断点文件
+func (cp *UploadCheckpoint) Done(partNumber int, etag string) error {
+	if cp == nil {
+		return nil
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.Parts[partNumber-1] = etag
+	return cp.save()
+}
+
+// SetParts 替换已完成的分块并写入断点文件
+func (cp *UploadCheckpoint) SetParts(parts []string) error {
+	if cp == nil {
+		return nil
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.Parts = append([]string(nil), parts...)
+	return cp.save()
+}
+
+// Save 写入断点文件
+func (cp *UploadCheckpoint) Save() error {
+	if cp == nil {
+		return nil
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return cp.save()
+}
+
+// save 调用方需持有锁
+func (cp *UploadCheckpoint) save() error {
+	return saveCheckpoint(cp.path, cp)
+}
+
+// Remove 删除断点文件
+func (cp *UploadCheckpoint) Remove() error {
+	if cp == nil {
+		return nil
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return removeCheckpoint(cp.path)
+}
+
+// DownloadCheckpoint 分块下载的断点,记录在下载临时文件旁
+type DownloadCheckpoint struct {
+	path string
+	mu   sync.Mutex
+
+	Bucket   string
+	Object   string
+	ETag     string
+	Size     int64
+	PartSize int
+	// Parts 分块是否已写入临时文件,下标为分块编号-1
+	Parts []bool
+}
+
+// LoadDownloadCheckpoint 读取断点文件,文件不存在或无法解析时返回nil
+func LoadDownloadCheckpoint(path string) *DownloadCheckpoint {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	cp := &DownloadCheckpoint{path: path}
+	if err = json.Unmarshal(data, cp); err != nil || cp.PartSize <= 0 {
+		return nil
+	}
+	return cp
+}
+
+// NewDownloadCheckpoint 创建断点
+func NewDownloadCheckpoint(path, bucket, object, etag string, size int64, partSize int) *DownloadCheckpoint {
+	return &DownloadCheckpoint{
+		path:     path,
+		Bucket:   bucket,
+		Object:   object,
+		ETag:     etag,
+		Size:     size,
+		PartSize: partSize,
+		Parts:    make([]bool, (int(size)+partSize-1)/partSize),
+	}
+}
+
+// Valid 断点是否属于同一对象且对象的ETag、大小未变化
+func (cp *DownloadCheckpoint) Valid(bucket, object, etag string, size int64) bool {
+	if cp == nil {
+		return false
+	}
+	return cp.Bucket == bucket && cp.Object == object && cp.ETag != "" && cp.ETag == etag &&
+		cp.Size == size && len(cp.Parts) == (int(size)+cp.PartSize-1)/cp.PartSize
+}
+
+// Done 记录完成的分块并写入断点文件
+func (cp *DownloadCheckpoint) Done(partNumber int) error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.Parts[partNumber-1] = true
+	return saveCheckpoint(cp.path, cp)
+}
+
+// Save 写入断点文件
+func (cp *DownloadCheckpoint) Save() error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return saveCheckpoint(cp.path, cp)
+}
+
+// Remove 删除断点文件
+func (cp *DownloadCheckpoint) Remove() error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return removeCheckpoint(cp.path)
+}
+
+// saveCheckpoint 先写入临时文件再重命名,避免中断时断点文件不完整
+func saveCheckpoint(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func removeCheckpoint(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testFileInfo 用于断点校验的文件信息
+type testFileInfo struct {
+	os.FileInfo
+	size    int64
+	modTime time.Time
+}
+
+func (i testFileInfo) Size() int64        { return i.size }
+func (i testFileInfo) ModTime() time.Time { return i.modTime }
+
+func TestUploadCheckpointValid(t *testing.T) {
+	modTime := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.FixedZone("CST", 8*3600))
+	info := testFileInfo{size: 25, modTime: modTime}
+	cp := NewUploadCheckpoint(filepath.Join(t.TempDir(), "cp"), "bucket", "object", "/data/file", info, 10, "upload-id")
+	if len(cp.Parts) != 3 {
+		t.Fatalf("len(Parts) = %d, want 3", len(cp.Parts))
+	}
+	tests := []struct {
+		name     string
+		bucket   string
+		object   string
+		filePath string
+		info     testFileInfo
+		parts    int
+		want     bool
+	}{
+		{"same file", "bucket", "object", "/data/file", info, 3, true},
+		{"same time in another zone", "bucket", "object", "/data/file", testFileInfo{size: 25, modTime: modTime.UTC()}, 3, true},
+		{"bucket changed", "other", "object", "/data/file", info, 3, false},
+		{"object changed", "bucket", "other", "/data/file", info, 3, false},
+		{"file path changed", "bucket", "object", "/data/other", info, 3, false},
+		{"size changed", "bucket", "object", "/data/file", testFileInfo{size: 26, modTime: modTime}, 3, false},
+		// 大小变化但分块数不变
+		{"size changed same parts", "bucket", "object", "/data/file", testFileInfo{size: 30, modTime: modTime}, 3, false},
+		{"mtime changed", "bucket", "object", "/data/file", testFileInfo{size: 25, modTime: modTime.Add(time.Nanosecond)}, 3, false},
+		{"part count changed", "bucket", "object", "/data/file", info, 2, false},
+	}
+	for _, tt := range tests {
+		c := NewUploadCheckpoint(cp.path, "bucket", "object", "/data/file", info, 10, "upload-id")
+		c.Parts = make([]string, tt.parts)
+		if got := c.Valid(tt.bucket, tt.object, tt.filePath, tt.info); got != tt.want {
+			t.Errorf("%s: Valid = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	var nilCheckpoint *UploadCheckpoint
+	if nilCheckpoint.Valid("bucket", "object", "/data/file", info) {
+		t.Error("nil checkpoint is valid")
+	}
+	if NewUploadCheckpoint("", "bucket", "object", "/data/file", info, 10, "upload-id") != nil {
+		t.Error("NewUploadCheckpoint with empty path != nil")
+	}
+}
+
+func TestUploadCheckpointRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cp")
+	modTime := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.Local)
+	info := testFileInfo{size: 25, modTime: modTime}
+	cp := NewUploadCheckpoint(path, "bucket", "dir/object", "/data/file", info, 10, "upload-id")
+	if err := cp.Done(2, `"etag2"`); err != nil {
+		t.Fatal(err)
+	}
+	loaded := LoadUploadCheckpoint(path)
+	if loaded == nil {
+		t.Fatal("LoadUploadCheckpoint = nil")
+	}
+	if !loaded.Valid("bucket", "dir/object", "/data/file", info) {
+		t.Errorf("loaded checkpoint is not valid: %+v", loaded)
+	}
+	if loaded.UploadID != "upload-id" || loaded.PartSize != 10 || len(loaded.Parts) != 3 || loaded.Parts[0] != "" || loaded.Parts[1] != `"etag2"` {
+		t.Errorf("loaded checkpoint = %+v", loaded)
+	}
+	if err := loaded.SetParts([]string{"a", "b", "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded := LoadUploadCheckpoint(path); reloaded == nil || reloaded.Parts[2] != "c" {
+		t.Errorf("reloaded checkpoint = %+v", reloaded)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temp file left behind: %v", err)
+	}
+	if err := loaded.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if LoadUploadCheckpoint(path) != nil {
+		t.Error("checkpoint loaded after Remove")
+	}
+	// 重复删除不报错
+	if err := loaded.Remove(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLoadUploadCheckpointInvalid(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"not json", "{"},
+		{"no upload id", `{"PartSize": 10, "Parts": ["", ""]}`},
+		{"no part size", `{"UploadID": "id", "Parts": ["", ""]}`},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		_ = os.WriteFile(path, []byte(tt.data), 0600)
+		if cp := LoadUploadCheckpoint(path); cp != nil {
+			t.Errorf("%s: LoadUploadCheckpoint = %+v, want nil", tt.name, cp)
+		}
+	}
+	if LoadUploadCheckpoint("") != nil || LoadUploadCheckpoint(filepath.Join(dir, "missing")) != nil {
+		t.Error("LoadUploadCheckpoint without file != nil")
+	}
+}
+
+func TestDownloadCheckpointValid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.s3tmp.cp")
+	tests := []struct {
+		name   string
+		bucket string
+		object string
+		etag   string
+		size   int64
+		want   bool
+	}{
+		{"same object", "bucket", "object", `"etag"`, 25, true},
+		{"bucket changed", "other", "object", `"etag"`, 25, false},
+		{"object changed", "bucket", "other", `"etag"`, 25, false},
+		{"etag changed", "bucket", "object", `"other"`, 25, false},
+		{"size changed", "bucket", "object", `"etag"`, 26, false},
+		// 大小变化但分块数不变
+		{"size changed same parts", "bucket", "object", `"etag"`, 30, false},
+		{"part count changed", "bucket", "object", `"etag"`, 35, false},
+	}
+	cp := NewDownloadCheckpoint(path, "bucket", "object", `"etag"`, 25, 10)
+	if len(cp.Parts) != 3 {
+		t.Fatalf("len(Parts) = %d, want 3", len(cp.Parts))
+	}
+	for _, tt := range tests {
+		if got := cp.Valid(tt.bucket, tt.object, tt.etag, tt.size); got != tt.want {
+			t.Errorf("%s: Valid = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	// 没有ETag时无法判断对象是否变化
+	if NewDownloadCheckpoint(path, "bucket", "object", "", 25, 10).Valid("bucket", "object", "", 25) {
+		t.Error("checkpoint without ETag is valid")
+	}
+	var nilCheckpoint *DownloadCheckpoint
+	if nilCheckpoint.Valid("bucket", "object", `"etag"`, 25) {
+		t.Error("nil checkpoint is valid")
+	}
+
+	if err := cp.Done(3); err != nil {
+		t.Fatal(err)
+	}
+	loaded := LoadDownloadCheckpoint(path)
+	if loaded == nil || !loaded.Valid("bucket", "object", `"etag"`, 25) || loaded.Parts[0] || !loaded.Parts[2] {
+		t.Errorf("loaded checkpoint = %+v", loaded)
+	}
+	if err := loaded.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if LoadDownloadCheckpoint(path) != nil {
+		t.Error("checkpoint loaded after Remove")
+	}
+}
@@ -0,0 +1,224 @@
+package internal
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"hash/crc64"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
+// checksumMD5 单次上传对象的ETag校验
+const checksumMD5 s3.ChecksumAlgorithm = "MD5"
+
+var checksumAlgorithms = []s3.ChecksumAlgorithm{s3.ChecksumCRC32, s3.ChecksumCRC32C, s3.ChecksumSHA1, s3.ChecksumSHA256, s3.ChecksumCRC64NVME}
+
+var (
+	crc32cTable    = crc32.MakeTable(crc32.Castagnoli)
+	crc64NVMETable = crc64.MakeTable(0x9a6c9329ac4bc9b5)
+)
+
+// newChecksumHash 算法对应的哈希,不支持的算法返回nil
+func newChecksumHash(algorithm s3.ChecksumAlgorithm) hash.Hash {
+	switch algorithm {
+	case s3.ChecksumCRC32:
+		return crc32.NewIEEE()
+	case s3.ChecksumCRC32C:
+		return crc32.New(crc32cTable)
+	case s3.ChecksumSHA1:
+		return sha1.New()
+	case s3.ChecksumSHA256:
+		return sha256.New()
+	case s3.ChecksumCRC64NVME:
+		return crc64.New(crc64NVMETable)
+	case checksumMD5:
+		return md5.New()
+	}
+	return nil
+}
+
+// checksumValue 校验和的字符串形式,MD5与ETag一致使用hex,其他使用base64
+func checksumValue(algorithm s3.ChecksumAlgorithm, h hash.Hash) string {
+	if algorithm == checksumMD5 {
+		return hex.EncodeToString(h.Sum(nil))
+	}
+	return Base64Encode(h.Sum(nil))
+}
+
+// ChecksumHeader 算法对应的请求头,如x-amz-checksum-crc32
+func ChecksumHeader(algorithm s3.ChecksumAlgorithm) string {
+	return "x-amz-checksum-" + strings.ToLower(string(algorithm))
+}
+
+// SetChecksum algorithm非空时计算body的校验和并设置请求头,读取后回到原位置
+// body不可Seek时无法预先计算,返回s3.ErrInvalidOption
+func SetChecksum(headers map[string]string, algorithm s3.ChecksumAlgorithm, body io.Reader) error {
+	if algorithm == "" {
+		return nil
+	}
+	h := newChecksumHash(algorithm)
+	if h == nil || algorithm == checksumMD5 {
+		return fmt.Errorf("%w: unsupported checksum algorithm %s", s3.ErrInvalidOption, algorithm)
+	}
+	if body == nil {
+		headers[ChecksumHeader(algorithm)] = checksumValue(algorithm, h)
+		return nil
+	}
+	rs, ok := body.(io.ReadSeeker)
+	if !ok {
+		return fmt.Errorf("%w: checksum %s requires a seekable body", s3.ErrInvalidOption, algorithm)
+	}
+	start, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(h, rs); err != nil {
+		return err
+	}
+	if _, err = rs.Seek(start, io.SeekStart); err != nil {
+		return err
+	}
+	headers[ChecksumHeader(algorithm)] = checksumValue(algorithm, h)
+	return nil
+}
+
+// SetChecksumAlgorithm 初始化分块上传时声明算法,CRC64NVME只支持完整对象校验和
+func SetChecksumAlgorithm(headers map[string]string, algorithm s3.ChecksumAlgorithm) {
+	if algorithm == "" {
+		return
+	}
+	headers["x-amz-checksum-algorithm"] = string(algorithm)
+	if algorithm == s3.ChecksumCRC64NVME {
+		headers["x-amz-checksum-type"] = "FULL_OBJECT"
+	}
+}
+
+// ResponseChecksum 响应头中的附加校验和,没有时返回空
+func ResponseChecksum(header http.Header) (s3.ChecksumAlgorithm, string) {
+	for _, algorithm := range checksumAlgorithms {
+		if value := header.Get(ChecksumHeader(algorithm)); value != "" {
+			return algorithm, value
+		}
+	}
+	return "", ""
+}
+
+// PartChecksum CopyPart、ListParts结果中分块的附加校验和
+func PartChecksum(c s3.PartChecksum) (s3.ChecksumAlgorithm, string) {
+	for algorithm, value := range map[s3.ChecksumAlgorithm]string{
+		s3.ChecksumCRC32:     c.ChecksumCRC32,
+		s3.ChecksumCRC32C:    c.ChecksumCRC32C,
+		s3.ChecksumSHA1:      c.ChecksumSHA1,
+		s3.ChecksumSHA256:    c.ChecksumSHA256,
+		s3.ChecksumCRC64NVME: c.ChecksumCRC64NVME,
+	} {
+		if value != "" {
+			return algorithm, value
+		}
+	}
+	return "", ""
+}
+
+// ChecksumElement 完成分块上传时分块的校验和元素,如<ChecksumCRC32>...</ChecksumCRC32>,没有时返回空
+func ChecksumElement(algorithm s3.ChecksumAlgorithm, value string) string {
+	if algorithm == "" || value == "" {
+		return ""
+	}
+	return fmt.Sprintf("<Checksum%s>%s</Checksum%s>", algorithm, value, algorithm)
+}
+
+// ExpectedChecksum 响应头中完整对象的校验和,没有时使用单次上传且未使用KMS、SSE-C加密的对象的MD5 ETag
+// 分块上传对象的组合校验和(值以-分块数结尾)及ETag无法校验,返回false
+func ExpectedChecksum(header http.Header) (s3.ChecksumAlgorithm, string, bool) {
+	if algorithm, value := ResponseChecksum(header); value != "" {
+		if header.Get("x-amz-checksum-type") != "COMPOSITE" && !strings.Contains(value, "-") {
+			return algorithm, value, true
+		}
+	}
+	if sse := header.Get("x-amz-server-side-encryption"); strings.HasPrefix(sse, "aws:kms") {
+		return "", "", false
+	}
+	if header.Get("x-amz-server-side-encryption-customer-algorithm") != "" {
+		return "", "", false
+	}
+	if etag, ok := etagMD5(header.Get("Etag")); ok {
+		return checksumMD5, etag, true
+	}
+	return "", "", false
+}
+
+// ChecksumWriter 写入w的同时计算响应头中可校验的校验和,用于完整对象的GET
+// 每次请求收到响应头时重新开始计算,重试时与w一起回退
+type ChecksumWriter struct {
+	w         io.Writer
+	operation string
+	bucket    string
+	key       string
+	algorithm s3.ChecksumAlgorithm
+	expected  string
+	hash      hash.Hash
+}
+
+// NewChecksumWriter 创建校验写入
+func NewChecksumWriter(w io.Writer, operation, bucket, key string) *ChecksumWriter {
+	return &ChecksumWriter{w: w, operation: operation, bucket: bucket, key: key}
+}
+
+// reset 根据响应头选择校验和并重新开始计算
+func (w *ChecksumWriter) reset(header http.Header) {
+	var ok bool
+	w.algorithm, w.expected, ok = ExpectedChecksum(header)
+	w.hash = nil
+	if ok {
+		w.hash = newChecksumHash(w.algorithm)
+	}
+}
+
+func (w *ChecksumWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if w.hash != nil {
+		_, _ = w.hash.Write(p[:n])
+	}
+	return n, err
+}
+
+// Verify 校验写入的内容,响应中没有可校验的值时返回nil
+func (w *ChecksumWriter) Verify() error {
+	if w.hash == nil {
+		return nil
+	}
+	if actual := checksumValue(w.algorithm, w.hash); actual != w.expected {
+		return &s3.ChecksumError{Operation: w.operation, Bucket: w.bucket, Key: w.key, Algorithm: w.algorithm, Expected: w.expected, Actual: actual}
+	}
+	return nil
+}
+
+// VerifyFileChecksum 按HEAD响应头校验下载的本地文件,响应中没有可校验的值时返回nil
+func VerifyFileChecksum(filePath string, header http.Header, operation, bucket, key string) error {
+	algorithm, expected, ok := ExpectedChecksum(header)
+	if !ok {
+		return nil
+	}
+	fd, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	h := newChecksumHash(algorithm)
+	if _, err = io.Copy(h, fd); err != nil {
+		return err
+	}
+	if actual := checksumValue(algorithm, h); actual != expected {
+		return &s3.ChecksumError{Operation: operation, Bucket: bucket, Key: key, Algorithm: algorithm, Expected: expected, Actual: actual}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
+// CRC的标准校验值,即"123456789"的CRC
+func TestChecksumCheckValue(t *testing.T) {
+	tests := []struct {
+		algorithm s3.ChecksumAlgorithm
+		want      uint64
+	}{
+		{s3.ChecksumCRC32, 0xCBF43926},
+		{s3.ChecksumCRC32C, 0xE3069283},
+		{s3.ChecksumCRC64NVME, 0xAE8B14860A799888},
+	}
+	for _, tt := range tests {
+		h := newChecksumHash(tt.algorithm)
+		_, _ = h.Write([]byte("123456789"))
+		sum := h.Sum(nil)
+		want := make([]byte, 8)
+		binary.BigEndian.PutUint64(want, tt.want)
+		want = want[8-len(sum):]
+		if !bytes.Equal(sum, want) {
+			t.Errorf("%s check value = %x, want %x", tt.algorithm, sum, want)
+		}
+		if got := checksumValue(tt.algorithm, h); got != base64.StdEncoding.EncodeToString(want) {
+			t.Errorf("%s checksum value = %s", tt.algorithm, got)
+		}
+	}
+}
+
+func TestSetChecksum(t *testing.T) {
+	body := strings.NewReader("xx123456789")
+	_, _ = body.Seek(2, 0)
+	headers := map[string]string{}
+	if err := SetChecksum(headers, s3.ChecksumCRC32, body); err != nil {
+		t.Fatal(err)
+	}
+	if got := headers["x-amz-checksum-crc32"]; got != "y/Q5Jg==" {
+		t.Errorf("x-amz-checksum-crc32 = %s, want y/Q5Jg==", got)
+	}
+	// 计算后回到原位置
+	if pos, _ := body.Seek(0, 1); pos != 2 {
+		t.Errorf("body position = %d, want 2", pos)
+	}
+	if err := SetChecksum(headers, "MD5", body); !errors.Is(err, s3.ErrInvalidOption) {
+		t.Errorf("SetChecksum(MD5) err = %v", err)
+	}
+	if err := SetChecksum(headers, "CRC16", body); !errors.Is(err, s3.ErrInvalidOption) {
+		t.Errorf("SetChecksum(CRC16) err = %v", err)
+	}
+
+	// 不可Seek的请求体无法预先计算校验和
+	headers = map[string]string{}
+	if err := SetChecksum(headers, s3.ChecksumCRC32, io.MultiReader(strings.NewReader("123456789"))); !errors.Is(err, s3.ErrInvalidOption) {
+		t.Errorf("SetChecksum(non-seekable) err = %v", err)
+	}
+	if len(headers) != 0 {
+		t.Errorf("SetChecksum(non-seekable) headers = %v", headers)
+	}
+	if err := SetChecksum(headers, s3.ChecksumCRC32, nil); err != nil || headers["x-amz-checksum-crc32"] != "AAAAAA==" {
+		t.Errorf("SetChecksum(nil) = %v, headers = %v", err, headers)
+	}
+}
+
+func TestExpectedChecksum(t *testing.T) {
+	const etag = `"d41d8cd98f00b204e9800998ecf8427e"`
+	tests := []struct {
+		name      string
+		header    http.Header
+		algorithm s3.ChecksumAlgorithm
+		value     string
+		ok        bool
+	}{
+		{"full object checksum", http.Header{"X-Amz-Checksum-Crc32": {"AAAAAA=="}, "Etag": {etag}}, s3.ChecksumCRC32, "AAAAAA==", true},
+		{"full object crc64nvme", http.Header{"X-Amz-Checksum-Crc64nvme": {"AAAAAAAAAAA="}, "X-Amz-Checksum-Type": {"FULL_OBJECT"}}, s3.ChecksumCRC64NVME, "AAAAAAAAAAA=", true},
+		// 组合校验和无法按完整内容校验,退回到ETag
+		{"composite checksum suffix", http.Header{"X-Amz-Checksum-Sha256": {"AAAA-3"}, "Etag": {`"d41d8cd98f00b204e9800998ecf8427e-3"`}}, "", "", false},
+		{"composite checksum type", http.Header{"X-Amz-Checksum-Crc32c": {"AAAAAA=="}, "X-Amz-Checksum-Type": {"COMPOSITE"}, "Etag": {etag}}, "MD5", "d41d8cd98f00b204e9800998ecf8427e", true},
+		{"md5 etag", http.Header{"Etag": {etag}}, "MD5", "d41d8cd98f00b204e9800998ecf8427e", true},
+		{"multipart etag", http.Header{"Etag": {`"d41d8cd98f00b204e9800998ecf8427e-2"`}}, "", "", false},
+		{"sse-kms", http.Header{"Etag": {etag}, "X-Amz-Server-Side-Encryption": {"aws:kms"}}, "", "", false},
+		{"dsse-kms", http.Header{"Etag": {etag}, "X-Amz-Server-Side-Encryption": {"aws:kms:dsse"}}, "", "", false},
+		{"sse-s3", http.Header{"Etag": {etag}, "X-Amz-Server-Side-Encryption": {"AES256"}}, "MD5", "d41d8cd98f00b204e9800998ecf8427e", true},
+		{"sse-c", http.Header{"Etag": {etag}, "X-Amz-Server-Side-Encryption-Customer-Algorithm": {"AES256"}}, "", "", false},
+		// KMS加密时附加校验和仍可校验
+		{"sse-kms checksum", http.Header{"X-Amz-Checksum-Sha1": {"2jmj7l5rSw0yVb/vlWAYkK/YBwk="}, "X-Amz-Server-Side-Encryption": {"aws:kms"}}, s3.ChecksumSHA1, "2jmj7l5rSw0yVb/vlWAYkK/YBwk=", true},
+		{"none", http.Header{}, "", "", false},
+	}
+	for _, tt := range tests {
+		algorithm, value, ok := ExpectedChecksum(tt.header)
+		if algorithm != tt.algorithm || value != tt.value || ok != tt.ok {
+			t.Errorf("%s: ExpectedChecksum = %q, %q, %v, want %q, %q, %v", tt.name, algorithm, value, ok, tt.algorithm, tt.value, tt.ok)
+		}
+	}
+}
+
+func TestChecksumWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewChecksumWriter(&buf, "Cat", "bucket", "key")
+	w.reset(http.Header{"X-Amz-Checksum-Crc32c": {"4waSgw=="}})
+	_, _ = w.Write([]byte("123456789"))
+	if err := w.Verify(); err != nil {
+		t.Errorf("Verify = %v", err)
+	}
+
+	// 重试时按新的响应头重新计算
+	buf.Reset()
+	w.reset(http.Header{"X-Amz-Checksum-Crc32c": {"4waSgw=="}})
+	_, _ = w.Write([]byte("12345678x"))
+	err := w.Verify()
+	var checksumErr *s3.ChecksumError
+	if !errors.As(err, &checksumErr) || !s3.IsChecksumMismatch(err) || checksumErr.Algorithm != s3.ChecksumCRC32C || checksumErr.Expected != "4waSgw==" {
+		t.Errorf("Verify = %v", err)
+	}
+
+	// 没有可校验的值
+	w.reset(http.Header{"Etag": {`"abc-2"`}})
+	_, _ = w.Write([]byte("anything"))
+	if err = w.Verify(); err != nil {
+		t.Errorf("Verify without checksum = %v", err)
+	}
+}
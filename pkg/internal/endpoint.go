@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// DefaultRegion 无法从服务地址识别region时使用的默认值
+const DefaultRegion = "us-east-1"
+
+// 常见服务地址中的region
+var regionPatterns = []*regexp.Regexp{
+	// AWS: s3.us-west-2.amazonaws.com、s3-us-west-2.amazonaws.com、s3.dualstack.us-west-2.amazonaws.com、s3.cn-north-1.amazonaws.com.cn
+	regexp.MustCompile(`^(?:[a-z0-9-]+\.)?s3[.-](?:dualstack\.)?([a-z]{2}(?:-gov)?-[a-z]+-\d+)\.amazonaws\.com(?:\.cn)?$`),
+	// 阿里云OSS: oss-cn-hangzhou.aliyuncs.com、oss-cn-hangzhou-internal.aliyuncs.com
+	regexp.MustCompile(`^oss-([a-z0-9-]+?)(?:-internal)?\.aliyuncs\.com$`),
+	// 腾讯云COS: cos.ap-guangzhou.myqcloud.com
+	regexp.MustCompile(`^cos\.([a-z0-9-]+)\.myqcloud\.com$`),
+	// 华为云OBS: obs.cn-north-1.myhuaweicloud.com
+	regexp.MustCompile(`^obs\.([a-z0-9-]+)\.myhuaweicloud\.com$`),
+	// 其他兼容服务: s3.<region>.example.com
+	regexp.MustCompile(`^s3[.-]([a-z]{2}-[a-z0-9-]+)\.[a-z0-9.-]+$`),
+}
+
+// Endpoint 服务地址
+type Endpoint struct {
+	Scheme string
+	Host   string
+	Path   string
+	// PathStyle 使用 host/bucket/key 形式访问
+	PathStyle bool
+}
+
+// ParseEndpoint 解析服务地址,支持 host、host:port 及带协议和路径的完整地址,未指定协议时默认使用https
+func ParseEndpoint(endpoint string) *Endpoint {
+	endpoint = strings.TrimSpace(endpoint)
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		//无法解析时保留指定的scheme
+		scheme, raw, _ := strings.Cut(endpoint, "://")
+		return &Endpoint{Scheme: strings.ToLower(scheme), Host: strings.TrimSuffix(raw, "/")}
+	}
+	return &Endpoint{
+		Scheme: strings.ToLower(u.Scheme),
+		Host:   u.Host,
+		Path:   strings.TrimSuffix(u.EscapedPath(), "/"),
+	}
+}
+
+// Region 根据服务地址识别region,无法识别时(如 s3.amazonaws.com、MinIO、自定义域名)返回us-east-1
+func (e *Endpoint) Region() string {
+	hostname := strings.ToLower(e.Host)
+	if h, _, err := net.SplitHostPort(hostname); err == nil {
+		hostname = h
+	}
+	for _, pattern := range regionPatterns {
+		if match := pattern.FindStringSubmatch(hostname); match != nil {
+			return match[1]
+		}
+	}
+	return DefaultRegion
+}
+
+// IsPathStyle 判断bucket是否使用path-style访问
+// 强制开启、服务地址为IP或localhost、bucket名称不能作为域名时使用path-style
+func (e *Endpoint) IsPathStyle(bucket string) bool {
+	if bucket == "" {
+		return false
+	}
+	if e.PathStyle {
+		return true
+	}
+	hostname := e.Host
+	if h, _, err := net.SplitHostPort(hostname); err == nil {
+		hostname = h
+	}
+	hostname = strings.Trim(hostname, "[]")
+	if hostname == "localhost" || net.ParseIP(hostname) != nil {
+		return true
+	}
+	return !IsDNSCompatibleBucket(bucket, e.Scheme == "https")
+}
+
+// BucketHost 获取请求的host
+func (e *Endpoint) BucketHost(bucket string) string {
+	if e.IsPathStyle(bucket) || bucket == "" {
+		return e.Host
+	}
+	return bucket + "." + e.Host
+}
+
+// URI 获取请求路径,object需已编码
+func (e *Endpoint) URI(bucket, object string) string {
+	if e.IsPathStyle(bucket) {
+		return e.Path + "/" + bucket + "/" + object
+	}
+	return e.Path + "/" + object
+}
+
+// URL 获取请求地址,object需已编码
+func (e *Endpoint) URL(bucket, object string) string {
+	return e.Scheme + "://" + e.BucketHost(bucket) + e.URI(bucket, object)
+}
+
+// IsDNSCompatibleBucket 判断bucket名称是否可作为虚拟主机的域名使用
+// https下带"."的bucket会导致证书校验失败,同样视为不兼容
+func IsDNSCompatibleBucket(bucket string, secure bool) bool {
+	if len(bucket) < 3 || len(bucket) > 63 {
+		return false
+	}
+	if secure && strings.Contains(bucket, ".") {
+		return false
+	}
+	if strings.Contains(bucket, "..") || net.ParseIP(bucket) != nil {
+		return false
+	}
+	for i := 0; i < len(bucket); i++ {
+		ch := bucket[i]
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= '0' && ch <= '9':
+		case ch == '-' || ch == '.':
+			if i == 0 || i == len(bucket)-1 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return !strings.Contains(bucket, ".-") && !strings.Contains(bucket, "-.")
+}
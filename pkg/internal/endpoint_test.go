@@ -0,0 +1,103 @@
+package internal
+
+import "testing"
+
+func TestParseEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     Endpoint
+	}{
+		{"s3.amazonaws.com", Endpoint{Scheme: "https", Host: "s3.amazonaws.com"}},
+		{" s3.amazonaws.com ", Endpoint{Scheme: "https", Host: "s3.amazonaws.com"}},
+		{"127.0.0.1:9000", Endpoint{Scheme: "https", Host: "127.0.0.1:9000"}},
+		{"http://127.0.0.1:9000", Endpoint{Scheme: "http", Host: "127.0.0.1:9000"}},
+		{"HTTPS://minio.local/", Endpoint{Scheme: "https", Host: "minio.local"}},
+		{"https://gateway.example.com/s3/", Endpoint{Scheme: "https", Host: "gateway.example.com", Path: "/s3"}},
+		{"http://[::1]:9000/a b", Endpoint{Scheme: "http", Host: "[::1]:9000", Path: "/a%20b"}},
+		// url.Parse失败时保留指定的scheme
+		{"HTTP://minio local:9000/", Endpoint{Scheme: "http", Host: "minio local:9000"}},
+		{"minio local:9000", Endpoint{Scheme: "https", Host: "minio local:9000"}},
+	}
+	for _, tt := range tests {
+		if got := ParseEndpoint(tt.endpoint); *got != tt.want {
+			t.Errorf("ParseEndpoint(%q) = %+v, want %+v", tt.endpoint, *got, tt.want)
+		}
+	}
+}
+
+func TestEndpointURL(t *testing.T) {
+	e := ParseEndpoint("https://gateway.example.com/s3")
+	if got := e.URL("bucket", "a/b.txt"); got != "https://bucket.gateway.example.com/s3/a/b.txt" {
+		t.Errorf("URL = %s", got)
+	}
+	if got := e.URL("", ""); got != "https://gateway.example.com/s3/" {
+		t.Errorf("URL = %s", got)
+	}
+	e.PathStyle = true
+	if got := e.URL("bucket", "a/b.txt"); got != "https://gateway.example.com/s3/bucket/a/b.txt" {
+		t.Errorf("URL = %s", got)
+	}
+}
+
+func TestIsPathStyle(t *testing.T) {
+	tests := []struct {
+		endpoint  string
+		pathStyle bool
+		bucket    string
+		want      bool
+	}{
+		{"s3.amazonaws.com", false, "my-bucket", false},
+		{"s3.amazonaws.com", false, "", false},
+		{"s3.amazonaws.com", true, "my-bucket", true},
+		{"s3.amazonaws.com", true, "", false},
+		{"127.0.0.1:9000", false, "my-bucket", true},
+		{"http://[::1]:9000", false, "my-bucket", true},
+		{"http://localhost:9000", false, "my-bucket", true},
+		// https下带"."的bucket证书校验失败
+		{"https://s3.amazonaws.com", false, "my.bucket", true},
+		{"http://s3.amazonaws.com", false, "my.bucket", false},
+		{"s3.amazonaws.com", false, "My_Bucket", true},
+		{"s3.amazonaws.com", false, "ab", true},
+		{"s3.amazonaws.com", false, "-bucket", true},
+		{"http://s3.amazonaws.com", false, "a..b", true},
+		{"http://s3.amazonaws.com", false, "a-.b", true},
+		{"http://s3.amazonaws.com", false, "192.168.1.1", true},
+	}
+	for _, tt := range tests {
+		e := ParseEndpoint(tt.endpoint)
+		e.PathStyle = tt.pathStyle
+		if got := e.IsPathStyle(tt.bucket); got != tt.want {
+			t.Errorf("%s pathStyle=%v IsPathStyle(%q) = %v, want %v", tt.endpoint, tt.pathStyle, tt.bucket, got, tt.want)
+		}
+	}
+}
+
+func TestEndpointRegion(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{"s3.us-west-2.amazonaws.com", "us-west-2"},
+		{"s3-us-west-2.amazonaws.com", "us-west-2"},
+		{"s3.dualstack.eu-central-1.amazonaws.com", "eu-central-1"},
+		{"bucket.s3.ap-northeast-1.amazonaws.com", "ap-northeast-1"},
+		{"s3.cn-north-1.amazonaws.com.cn", "cn-north-1"},
+		{"s3.us-gov-west-1.amazonaws.com", "us-gov-west-1"},
+		{"https://S3.US-WEST-2.AMAZONAWS.COM:443", "us-west-2"},
+		{"oss-cn-hangzhou.aliyuncs.com", "cn-hangzhou"},
+		{"oss-cn-hangzhou-internal.aliyuncs.com", "cn-hangzhou"},
+		{"cos.ap-guangzhou.myqcloud.com", "ap-guangzhou"},
+		{"obs.cn-north-4.myhuaweicloud.com", "cn-north-4"},
+		{"s3.fr-par.scw.cloud", "fr-par"},
+		{"s3.amazonaws.com", DefaultRegion},
+		{"127.0.0.1:9000", DefaultRegion},
+		{"http://localhost:9000", DefaultRegion},
+		{"minio.example.com", DefaultRegion},
+		{"storage", DefaultRegion},
+	}
+	for _, tt := range tests {
+		if got := ParseEndpoint(tt.endpoint).Region(); got != tt.want {
+			t.Errorf("Region(%q) = %q, want %q", tt.endpoint, got, tt.want)
+		}
+	}
+}
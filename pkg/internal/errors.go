@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
+// NewResponseError 根据响应头和响应体生成错误
+func NewResponseError(operation, bucket, key string, header http.Header, body []byte) *s3.ResponseError {
+	statusCode, _ := strconv.Atoi(header.Get("StatusCode"))
+	var errorMsg = &s3.Error{}
+	_ = xml.Unmarshal(body, errorMsg)
+	respErr := &s3.ResponseError{
+		Operation:  operation,
+		Bucket:     bucket,
+		Key:        key,
+		StatusCode: statusCode,
+		Code:       errorMsg.Code,
+		Message:    errorMsg.Message,
+		RequestID:  header.Get("X-Amz-Request-Id"),
+		HostID:     header.Get("X-Amz-Id-2"),
+		Body:       body,
+	}
+	if respErr.RequestID == "" {
+		respErr.RequestID = errorMsg.RequestID
+	}
+	if respErr.HostID == "" {
+		respErr.HostID = errorMsg.HostID
+	}
+	return respErr
+}
@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
+// Limits 分块大小和并发数的取值范围
+type Limits struct {
+	PartMinSize  int
+	PartMaxSize  int
+	ThreadMinNum int
+	ThreadMaxNum int
+}
+
+// NewLimits 根据配置生成取值范围
+func NewLimits(cfg *s3.Config) Limits {
+	return Limits{
+		PartMinSize:  cfg.PartMinSize,
+		PartMaxSize:  cfg.PartMaxSize,
+		ThreadMinNum: cfg.ThreadMinNum,
+		ThreadMaxNum: cfg.ThreadMaxNum,
+	}
+}
+
+// PartSize 校验分块大小,未设置时返回defaultSize
+func (l Limits) PartSize(partSize int64, defaultSize int) (int, error) {
+	if partSize == 0 {
+		return defaultSize, nil
+	}
+	if partSize < int64(l.PartMinSize) || partSize > int64(l.PartMaxSize) {
+		return 0, fmt.Errorf("%w: PartSize %d out of range [%d, %d]", s3.ErrInvalidOption, partSize, l.PartMinSize, l.PartMaxSize)
+	}
+	return int(partSize), nil
+}
+
+// ThreadNum 校验并发数,未设置时返回最大并发数
+func (l Limits) ThreadNum(threadNum int) (int, error) {
+	if threadNum == 0 {
+		return l.ThreadMaxNum, nil
+	}
+	if threadNum < l.ThreadMinNum || threadNum > l.ThreadMaxNum {
+		return 0, fmt.Errorf("%w: ThreadNum %d out of range [%d, %d]", s3.ErrInvalidOption, threadNum, l.ThreadMinNum, l.ThreadMaxNum)
+	}
+	return threadNum, nil
+}
+
+// 以下为旧接口map参数的转换,与旧接口一致,非法值忽略并使用默认值
+
+func (l Limits) partSize(options map[string]string) int64 {
+	n, _ := strconv.Atoi(options["part_size"])
+	if n <= l.PartMaxSize && n >= l.PartMinSize {
+		return int64(n)
+	}
+	return 0
+}
+
+func (l Limits) threadNum(options map[string]string) int {
+	n, _ := strconv.Atoi(options["thread_num"])
+	if n <= l.ThreadMaxNum && n >= l.ThreadMinNum {
+		return n
+	}
+	return 0
+}
+
+func maxKeys(options map[string]string, key string) int {
+	n, _ := strconv.Atoi(options[key])
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// BucketOptions 转换bucket参数
+func BucketOptions(options map[string]string) *s3.BucketOptions {
+	return &s3.BucketOptions{ACL: options["acl"]}
+}
+
+// LifecycleOptions 转换生命周期参数
+func LifecycleOptions(options map[string]string) *s3.LifecycleOptions {
+	expiration, _ := strconv.Atoi(options["expiration"])
+	return &s3.LifecycleOptions{Prefix: options["prefix"], Expiration: expiration}
+}
+
+// ListPartOptions 转换分块上传列表参数
+func ListPartOptions(options map[string]string) *s3.ListPartOptions {
+	return &s3.ListPartOptions{
+		Prefix:     options["prefix"],
+		Delimiter:  options["delimiter"],
+		KeyMarker:  options["key-marker"],
+		MaxUploads: maxKeys(options, "max-keys"),
+	}
+}
+
+// ListOptions 转换对象列表参数
+func ListOptions(options map[string]string) *s3.ListOptions {
+	return &s3.ListOptions{
+		Prefix:    options["prefix"],
+		Delimiter: options["delimiter"],
+		Marker:    options["marker"],
+		MaxKeys:   maxKeys(options, "max-keys"),
+	}
+}
+
+// PutOptions 转换上传参数
+func PutOptions(options map[string]string) *s3.PutOptions {
+	return &s3.PutOptions{ACL: options["acl"], Disposition: options["disposition"]}
+}
+
+// CopyOptions 转换复制参数
+func CopyOptions(options map[string]string) *s3.CopyOptions {
+	return &s3.CopyOptions{ACL: options["acl"], Disposition: options["disposition"]}
+}
+
+// GetOptions 转换下载参数
+func (l Limits) GetOptions(options map[string]string) *s3.GetOptions {
+	return &s3.GetOptions{PartSize: l.partSize(options), ThreadNum: l.threadNum(options)}
+}
+
+// MultipartOptions 转换分块参数
+func (l Limits) MultipartOptions(options map[string]string) *s3.MultipartOptions {
+	return &s3.MultipartOptions{
+		ACL:         options["acl"],
+		Disposition: options["disposition"],
+		PartSize:    l.partSize(options),
+		ThreadNum:   l.threadNum(options),
+		Checkpoint:  options["checkpoint"],
+		UploadID:    options["upload_id"],
+	}
+}
+
+// BulkOptions 转换批量操作参数
+func (l Limits) BulkOptions(options map[string]string) *s3.BulkOptions {
+	var suffix []string
+	for _, tmpSuffix := range strings.Split(options["suffix"], ",") {
+		if tmpSuffix != "" {
+			suffix = append(suffix, tmpSuffix)
+		}
+	}
+	expired, _ := strconv.Atoi(options["expired"])
+	return &s3.BulkOptions{
+		ACL:       options["acl"],
+		PartSize:  l.partSize(options),
+		ThreadNum: l.threadNum(options),
+		MaxKeys:   maxKeys(options, "max-keys"),
+		Replace:   options["replace"] == "true",
+		FullPath:  options["full_path"] == "true",
+		Suffix:    suffix,
+		Expired:   time.Duration(expired) * time.Second,
+	}
+}
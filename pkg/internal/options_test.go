@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
+func TestNewLimits(t *testing.T) {
+	l := NewLimits(s3.NewConfig())
+	if l != (Limits{PartMinSize: 1 << 20, PartMaxSize: 10 << 20, ThreadMinNum: 1, ThreadMaxNum: 10}) {
+		t.Errorf("default Limits = %+v", l)
+	}
+
+	l = NewLimits(s3.NewConfig(s3.WithPartSize(5<<20, 100<<20), s3.WithThreadNum(2, 4)))
+	tests := []struct {
+		partSize   int64
+		threadNum  int
+		wantPart   int
+		wantThread int
+		invalid    bool
+	}{
+		// 未设置时使用默认分块大小及最大并发数
+		{0, 0, 5 << 20, 4, false},
+		{5 << 20, 2, 5 << 20, 2, false},
+		{100 << 20, 4, 100 << 20, 4, false},
+		{1 << 20, 0, 0, 0, true},
+		{200 << 20, 0, 0, 0, true},
+		{0, 1, 0, 0, true},
+		{0, 5, 0, 0, true},
+	}
+	for _, tt := range tests {
+		partSize, pErr := l.PartSize(tt.partSize, l.PartMinSize)
+		threadNum, tErr := l.ThreadNum(tt.threadNum)
+		if tt.invalid {
+			if !errors.Is(pErr, s3.ErrInvalidOption) && !errors.Is(tErr, s3.ErrInvalidOption) {
+				t.Errorf("PartSize(%d), ThreadNum(%d) err = %v, %v, want ErrInvalidOption", tt.partSize, tt.threadNum, pErr, tErr)
+			}
+			continue
+		}
+		if pErr != nil || tErr != nil || partSize != tt.wantPart || threadNum != tt.wantThread {
+			t.Errorf("PartSize(%d), ThreadNum(%d) = %d, %d, %v, %v", tt.partSize, tt.threadNum, partSize, threadNum, pErr, tErr)
+		}
+	}
+}
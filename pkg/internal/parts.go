@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"strings"
+)
+
+// ResumeParts 返回已上传分块中可以继续使用的ETag及校验和元素(见ChecksumElement),下标为分块编号-1,需要重新上传的为空
+// 分块大小需与按partSize切分的大小一致;known中记录了相同ETag的分块直接使用,
+// 否则r不为nil且ETag为MD5时校验r中对应范围的MD5
+func ResumeParts(parts []PartInfo, known []string, r io.ReaderAt, size int64, partSize int) ([]string, []string, error) {
+	total := (int(size) + partSize - 1) / partSize
+	etags := make([]string, total)
+	checksums := make([]string, total)
+	for _, part := range parts {
+		index := part.PartNumber - 1
+		if index < 0 || index >= total {
+			continue
+		}
+		offset := int64(index) * int64(partSize)
+		if part.Size != min(int64(partSize), size-offset) {
+			continue
+		}
+		if (index >= len(known) || known[index] != part.ETag) && r != nil {
+			sum, ok := etagMD5(part.ETag)
+			if ok {
+				h := md5.New()
+				if _, err := io.Copy(h, io.NewSectionReader(r, offset, part.Size)); err != nil {
+					return nil, nil, err
+				}
+				if hex.EncodeToString(h.Sum(nil)) != sum {
+					continue
+				}
+			}
+		}
+		etags[index] = part.ETag
+		checksums[index] = ChecksumElement(part.ChecksumAlgorithm, part.Checksum)
+	}
+	return etags, checksums, nil
+}
+
+// etagMD5 ETag为内容的MD5时返回该值,加密等情况下ETag不是MD5
+func etagMD5(etag string) (string, bool) {
+	etag = strings.ToLower(strings.Trim(etag, `"`))
+	if len(etag) != 32 {
+		return "", false
+	}
+	if _, err := hex.DecodeString(etag); err != nil {
+		return "", false
+	}
+	return etag, true
+}
@@ -0,0 +1,133 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
+func TestResumeParts(t *testing.T) {
+	data := []byte(strings.Repeat("0123456789", 2) + "abcde")
+	size, partSize := int64(len(data)), 10
+	etag := func(b []byte) string {
+		sum := md5.Sum(b)
+		return `"` + hex.EncodeToString(sum[:]) + `"`
+	}
+	part1, part2, part3 := etag(data[:10]), etag(data[10:20]), etag(data[20:])
+	// 加密等情况下ETag不是MD5,无法校验
+	const opaque = `"opaque-etag"`
+	wrong := etag([]byte("other"))
+	tests := []struct {
+		name  string
+		parts []PartInfo
+		known []string
+		r     bool
+		want  []string
+	}{
+		{
+			name:  "md5 match",
+			parts: []PartInfo{{PartNumber: 1, Size: 10, ETag: part1}, {PartNumber: 2, Size: 10, ETag: part2}, {PartNumber: 3, Size: 5, ETag: part3}},
+			r:     true,
+			want:  []string{part1, part2, part3},
+		},
+		{
+			name:  "md5 mismatch",
+			parts: []PartInfo{{PartNumber: 1, Size: 10, ETag: wrong}, {PartNumber: 2, Size: 10, ETag: part2}},
+			r:     true,
+			want:  []string{"", part2, ""},
+		},
+		{
+			name:  "size mismatch",
+			parts: []PartInfo{{PartNumber: 1, Size: 9, ETag: part1}, {PartNumber: 3, Size: 10, ETag: part3}},
+			r:     true,
+			want:  []string{"", "", ""},
+		},
+		{
+			name:  "part number out of range",
+			parts: []PartInfo{{PartNumber: 0, Size: 10, ETag: part1}, {PartNumber: 4, Size: 5, ETag: part3}},
+			r:     true,
+			want:  []string{"", "", ""},
+		},
+		{
+			// 断点中记录的ETag不再校验MD5
+			name:  "known etag",
+			parts: []PartInfo{{PartNumber: 1, Size: 10, ETag: wrong}, {PartNumber: 2, Size: 10, ETag: opaque}},
+			known: []string{wrong, opaque},
+			r:     true,
+			want:  []string{wrong, opaque, ""},
+		},
+		{
+			// 未知且不是MD5的ETag只校验大小
+			name:  "unknown opaque etag",
+			parts: []PartInfo{{PartNumber: 2, Size: 10, ETag: opaque}},
+			r:     true,
+			want:  []string{"", opaque, ""},
+		},
+		{
+			name:  "known etag changed",
+			parts: []PartInfo{{PartNumber: 1, Size: 10, ETag: wrong}},
+			known: []string{part1},
+			r:     true,
+			want:  []string{"", "", ""},
+		},
+		{
+			// 复制等没有本地数据时只校验大小
+			name:  "no reader",
+			parts: []PartInfo{{PartNumber: 1, Size: 10, ETag: wrong}, {PartNumber: 3, Size: 5, ETag: opaque}},
+			want:  []string{wrong, "", opaque},
+		},
+	}
+	for _, tt := range tests {
+		var r io.ReaderAt
+		if tt.r {
+			r = bytes.NewReader(data)
+		}
+		etags, _, err := ResumeParts(tt.parts, tt.known, r, size, partSize)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if strings.Join(etags, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: etags = %v, want %v", tt.name, etags, tt.want)
+		}
+	}
+}
+
+func TestResumePartsChecksum(t *testing.T) {
+	parts := []PartInfo{
+		{PartNumber: 1, Size: 10, ETag: `"opaque"`, ChecksumAlgorithm: s3.ChecksumCRC32, Checksum: "AAAAAA=="},
+		{PartNumber: 2, Size: 5, ETag: `"opaque2"`},
+	}
+	etags, checksums, err := ResumeParts(parts, nil, nil, 15, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(etags) != 2 || checksums[0] != "<ChecksumCRC32>AAAAAA==</ChecksumCRC32>" || checksums[1] != "" {
+		t.Errorf("etags = %v, checksums = %v", etags, checksums)
+	}
+}
+
+func TestEtagMD5(t *testing.T) {
+	tests := []struct {
+		etag string
+		want string
+		ok   bool
+	}{
+		{`"D41D8CD98F00B204E9800998ECF8427E"`, "d41d8cd98f00b204e9800998ecf8427e", true},
+		{"d41d8cd98f00b204e9800998ecf8427e", "d41d8cd98f00b204e9800998ecf8427e", true},
+		{`"d41d8cd98f00b204e9800998ecf8427e-2"`, "", false},
+		{`"z41d8cd98f00b204e9800998ecf8427e"`, "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := etagMD5(tt.etag)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("etagMD5(%q) = %q, %v, want %q, %v", tt.etag, got, ok, tt.want, tt.ok)
+		}
+	}
+}
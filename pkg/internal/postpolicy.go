@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
+// PostPolicy 根据条件生成base64编码的policy及表单字段
+// signFields为签名相关的字段,如x-amz-credential,同时作为policy的条件
+func PostPolicy(policy *s3.PostPolicy, now time.Time, signFields map[string]string) (string, map[string]string, error) {
+	if policy == nil || policy.Bucket == "" {
+		return "", nil, fmt.Errorf("%w: PostPolicy Bucket is required", s3.ErrInvalidOption)
+	}
+	if policy.Key == "" && policy.KeyPrefix == "" {
+		return "", nil, fmt.Errorf("%w: PostPolicy Key or KeyPrefix is required", s3.ErrInvalidOption)
+	}
+	if policy.Key != "" && !strings.HasPrefix(policy.Key, policy.KeyPrefix) {
+		return "", nil, fmt.Errorf("%w: PostPolicy Key %s does not start with KeyPrefix %s", s3.ErrInvalidOption, policy.Key, policy.KeyPrefix)
+	}
+	if policy.MinSize < 0 || policy.MaxSize < 0 || (policy.MaxSize > 0 && policy.MinSize > policy.MaxSize) {
+		return "", nil, fmt.Errorf("%w: PostPolicy size range [%d, %d]", s3.ErrInvalidOption, policy.MinSize, policy.MaxSize)
+	}
+	expires := policy.Expires
+	if expires == 0 {
+		expires = time.Hour
+	}
+	if expires < 0 {
+		return "", nil, fmt.Errorf("%w: PostPolicy Expires %s must be positive", s3.ErrInvalidOption, expires)
+	}
+	fields := map[string]string{}
+	conditions := []interface{}{
+		map[string]string{"bucket": policy.Bucket},
+	}
+	if policy.KeyPrefix != "" {
+		conditions = append(conditions, []string{"starts-with", "$key", policy.KeyPrefix})
+		fields["key"] = policy.KeyPrefix + "${filename}"
+	}
+	if policy.Key != "" {
+		if policy.KeyPrefix == "" {
+			conditions = append(conditions, map[string]string{"key": policy.Key})
+		}
+		fields["key"] = policy.Key
+	}
+	if policy.ACL != "" {
+		conditions = append(conditions, map[string]string{"acl": policy.ACL})
+		fields["acl"] = policy.ACL
+	}
+	if policy.ContentType != "" {
+		conditions = append(conditions, map[string]string{"Content-Type": policy.ContentType})
+		fields["Content-Type"] = policy.ContentType
+	}
+	if policy.MaxSize > 0 {
+		conditions = append(conditions, []interface{}{"content-length-range", policy.MinSize, policy.MaxSize})
+	}
+	keys := make([]string, 0, len(signFields))
+	for k := range signFields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		conditions = append(conditions, map[string]string{k: signFields[k]})
+		fields[k] = signFields[k]
+	}
+	document, err := json.Marshal(map[string]interface{}{
+		"expiration": now.Add(expires).UTC().Format("2006-01-02T15:04:05.000Z"),
+		"conditions": conditions,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	encoded := base64.StdEncoding.EncodeToString(document)
+	fields["policy"] = encoded
+	return encoded, fields, nil
+}
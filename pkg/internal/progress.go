@@ -0,0 +1,277 @@
+package internal
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
+// Progress 操作的进度统计,事件在独立的goroutine中回调listener
+// nil表示不统计进度,所有方法都可以在nil上调用
+type Progress struct {
+	// parent 批量操作中单个文件的进度,只把传输的字节数汇总到parent
+	parent   *Progress
+	listener s3.ProgressListener
+	start    time.Time
+
+	mu            sync.Mutex
+	consumed      int64
+	total         int64
+	finishedFiles int
+	totalFiles    int
+	queue         []*s3.ProgressEvent
+	closed        bool
+	wake          chan struct{}
+	done          chan struct{}
+}
+
+// NewProgress 创建进度统计,listener为nil时返回nil
+// listener为批量操作的*Progress时,创建的进度只把传输的字节数汇总到批量操作
+func NewProgress(listener s3.ProgressListener) *Progress {
+	if listener == nil {
+		return nil
+	}
+	if parent, ok := listener.(*Progress); ok {
+		if parent == nil {
+			return nil
+		}
+		return &Progress{parent: parent}
+	}
+	p := &Progress{
+		listener: listener,
+		start:    time.Now(),
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	go p.loop()
+	return p
+}
+
+// ProgressChanged 实现s3.ProgressListener,用于把*Progress作为单个文件操作的listener
+func (p *Progress) ProgressChanged(event *s3.ProgressEvent) {
+	if event.Type == s3.TransferData {
+		p.Transferred(event.RwBytes)
+	}
+}
+
+// Start 设置总字节数和文件数,发送TransferStarted事件
+func (p *Progress) Start(totalBytes int64, totalFiles int) {
+	if p == nil || p.parent != nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total = totalBytes
+	p.totalFiles = totalFiles
+	p.emit(&s3.ProgressEvent{Type: s3.TransferStarted})
+}
+
+// AddTotal 增加总字节数和文件数,用于批量操作分页列举
+func (p *Progress) AddTotal(totalBytes int64, totalFiles int) {
+	if p == nil || p.parent != nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total += totalBytes
+	p.totalFiles += totalFiles
+}
+
+// Transferred 记录传输的字节数
+func (p *Progress) Transferred(n int64) {
+	if p == nil {
+		return
+	}
+	if p.parent != nil {
+		p.parent.Transferred(n)
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.consumed += n
+	p.emit(&s3.ProgressEvent{Type: s3.TransferData, RwBytes: n})
+}
+
+// FileStarted 批量操作开始处理一个文件
+func (p *Progress) FileStarted(key string) {
+	p.file(s3.FileStarted, key, 0, nil)
+}
+
+// FileCompleted 批量操作完成一个文件
+func (p *Progress) FileCompleted(key string) {
+	p.file(s3.FileCompleted, key, 0, nil)
+}
+
+// FileSkipped 批量操作跳过一个文件,size从总字节数中扣除
+func (p *Progress) FileSkipped(key string, size int64) {
+	p.file(s3.FileSkipped, key, size, nil)
+}
+
+// FileFailed 批量操作中一个文件失败
+func (p *Progress) FileFailed(key string, err error) {
+	p.file(s3.FileFailed, key, 0, err)
+}
+
+func (p *Progress) file(eventType s3.ProgressEventType, key string, size int64, err error) {
+	if p == nil || p.parent != nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if eventType != s3.FileStarted {
+		p.finishedFiles++
+	}
+	p.total -= size
+	p.emit(&s3.ProgressEvent{Type: eventType, Key: key, Err: err})
+}
+
+// Close 发送TransferCompleted或TransferFailed事件,并等待所有事件回调完成
+func (p *Progress) Close(err error) {
+	if p == nil || p.parent != nil {
+		return
+	}
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	if err != nil {
+		p.emit(&s3.ProgressEvent{Type: s3.TransferFailed, Err: err})
+	} else {
+		p.emit(&s3.ProgressEvent{Type: s3.TransferCompleted})
+	}
+	p.closed = true
+	p.mu.Unlock()
+	<-p.done
+}
+
+// emit 填充统计信息并加入队列,调用方需持有锁
+func (p *Progress) emit(event *s3.ProgressEvent) {
+	if p.closed {
+		return
+	}
+	event.ConsumedBytes = p.consumed
+	event.TotalBytes = p.total
+	event.FinishedFiles = p.finishedFiles
+	event.TotalFiles = p.totalFiles
+	if elapsed := time.Since(p.start).Seconds(); elapsed > 0 {
+		event.Speed = float64(p.consumed) / elapsed
+	}
+	if event.Speed > 0 && p.total > p.consumed {
+		event.ETA = time.Duration(float64(p.total-p.consumed) / event.Speed * float64(time.Second))
+	}
+	//listener未处理的TransferData事件合并为一个
+	if n := len(p.queue); n > 0 && event.Type == s3.TransferData && p.queue[n-1].Type == s3.TransferData {
+		event.RwBytes += p.queue[n-1].RwBytes
+		p.queue[n-1] = event
+	} else {
+		p.queue = append(p.queue, event)
+	}
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (p *Progress) loop() {
+	defer close(p.done)
+	for range p.wake {
+		p.mu.Lock()
+		events := p.queue
+		p.queue = nil
+		closed := p.closed
+		p.mu.Unlock()
+		for _, event := range events {
+			p.listener.ProgressChanged(event)
+		}
+		if closed {
+			return
+		}
+	}
+}
+
+// SendPercent 向旧接口的percentChan发送进度,ch无人读取时在独立的goroutine中发送,不阻塞传输
+func SendPercent(ch chan int, total int) {
+	if ch == nil {
+		return
+	}
+	select {
+	case ch <- total:
+	default:
+		go func() {
+			ch <- total
+		}()
+	}
+}
+
+// ProgressReader 读取时按字节统计进度,最多统计size字节
+// 重试时请求体回退后重复读取的字节不会重复统计,r可Seek时返回的Reader也可Seek
+func ProgressReader(r io.Reader, progress *Progress, size int64) io.Reader {
+	if progress == nil || r == nil {
+		return r
+	}
+	pr := &progressReader{r: r, progress: progress, size: size}
+	if seeker, ok := r.(io.Seeker); ok {
+		return &progressReadSeeker{progressReader: pr, seeker: seeker}
+	}
+	return pr
+}
+
+type progressReader struct {
+	r        io.Reader
+	progress *Progress
+	size     int64
+	// offset 当前读取位置,reported 已统计的位置
+	offset   int64
+	reported int64
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	pr.offset += int64(n)
+	if offset := min(pr.offset, pr.size); offset > pr.reported {
+		pr.progress.Transferred(offset - pr.reported)
+		pr.reported = offset
+	}
+	return n, err
+}
+
+type progressReadSeeker struct {
+	*progressReader
+	seeker io.Seeker
+}
+
+func (pr *progressReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	n, err := pr.seeker.Seek(offset, whence)
+	if err == nil {
+		pr.offset = n
+	}
+	return n, err
+}
+
+// ProgressWriter 写入时按字节统计进度,重试时dsc回退后重复写入的字节不会重复统计
+func ProgressWriter(w io.Writer, progress *Progress) io.Writer {
+	if progress == nil || w == nil {
+		return w
+	}
+	return &progressWriter{w: w, progress: progress}
+}
+
+type progressWriter struct {
+	w        io.Writer
+	progress *Progress
+	written  int64
+	reported int64
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	pw.written += int64(n)
+	if pw.written > pw.reported {
+		pw.progress.Transferred(pw.written - pw.reported)
+		pw.reported = pw.written
+	}
+	return n, err
+}
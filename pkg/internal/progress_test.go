@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
+// progressRecorder 记录进度事件
+type progressRecorder struct {
+	mu     sync.Mutex
+	events []*s3.ProgressEvent
+}
+
+func (r *progressRecorder) ProgressChanged(event *s3.ProgressEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// rwBytes 返回TransferData事件的字节数之和
+func (r *progressRecorder) rwBytes() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var total int64
+	for _, event := range r.events {
+		if event.Type == s3.TransferData {
+			total += event.RwBytes
+		}
+	}
+	return total
+}
+
+func (r *progressRecorder) last() *s3.ProgressEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.events[len(r.events)-1]
+}
+
+func TestProgressReader(t *testing.T) {
+	recorder := &progressRecorder{}
+	progress := NewProgress(recorder)
+	progress.Start(10, 0)
+	r := ProgressReader(strings.NewReader("0123456789"), progress, 10)
+	if _, ok := r.(io.Seeker); !ok {
+		t.Fatal("ProgressReader of a seekable reader is not seekable")
+	}
+	buf := make([]byte, 4)
+	_, _ = r.Read(buf)
+	//回退后重复读取的字节不重复统计
+	_, _ = r.(io.Seeker).Seek(0, io.SeekStart)
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		t.Fatal(err)
+	}
+	progress.Close(nil)
+	if total := recorder.rwBytes(); total != 10 {
+		t.Errorf("RwBytes = %d, want 10", total)
+	}
+	if event := recorder.last(); event.Type != s3.TransferCompleted || event.ConsumedBytes != 10 {
+		t.Errorf("last event = %+v", event)
+	}
+
+	//最多统计size字节,如aws-chunked编码后的请求体
+	recorder = &progressRecorder{}
+	progress = NewProgress(recorder)
+	if _, ok := ProgressReader(io.MultiReader(strings.NewReader("0123456789")), progress, 5).(io.Seeker); ok {
+		t.Error("ProgressReader of a non-seekable reader is seekable")
+	}
+	_, _ = io.Copy(io.Discard, ProgressReader(strings.NewReader("0123456789"), progress, 5))
+	progress.Close(nil)
+	if total := recorder.rwBytes(); total != 5 {
+		t.Errorf("RwBytes = %d, want 5", total)
+	}
+
+	if r := strings.NewReader(""); ProgressReader(r, nil, 0) != io.Reader(r) {
+		t.Error("ProgressReader without progress wraps the reader")
+	}
+}
+
+func TestProgressWriterRewind(t *testing.T) {
+	recorder := &progressRecorder{}
+	progress := NewProgress(recorder)
+	dsc := &bytes.Buffer{}
+	w := ProgressWriter(dsc, progress)
+	rewind := writerRewinder(w)
+	if rewind == nil {
+		t.Fatal("writerRewinder(ProgressWriter) = nil")
+	}
+	_, _ = w.Write([]byte("0123"))
+	if err := rewind(); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write([]byte("012345"))
+	progress.Close(nil)
+	if dsc.String() != "012345" {
+		t.Errorf("dsc = %q", dsc.String())
+	}
+	if total := recorder.rwBytes(); total != 6 {
+		t.Errorf("RwBytes = %d, want 6", total)
+	}
+	if writerRewinder(ProgressWriter(io.Discard, progress)) != nil {
+		t.Error("writerRewinder of a non-rewindable writer != nil")
+	}
+}
+
+func TestCURLProgress(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = io.Copy(io.Discard, r.Body)
+		if requests == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}))
+	defer srv.Close()
+	c := NewClient(&s3.Config{HTTPClient: srv.Client(), RetryPolicy: s3.NewRetryPolicy(1)})
+
+	recorder := &progressRecorder{}
+	progress := NewProgress(recorder)
+	content := strings.Repeat("x", 100<<10)
+	body := ProgressReader(strings.NewReader(content), progress, int64(len(content)))
+	header, _, err := c.CURL(context.Background(), srv.URL, "PUT", nil, body, nil)
+	progress.Close(err)
+	if err != nil || header.Get("StatusCode") != "200" || requests != 2 {
+		t.Fatalf("StatusCode = %s, requests = %d, err = %v", header.Get("StatusCode"), requests, err)
+	}
+	//第一次请求读取的字节不重复统计
+	if total := recorder.rwBytes(); total != int64(len(content)) {
+		t.Errorf("RwBytes = %d, want %d", total, len(content))
+	}
+}
+
+func TestSendPercent(t *testing.T) {
+	SendPercent(nil, 1)
+	ch := make(chan int)
+	// 无人读取时不阻塞,也不丢弃
+	for i := 0; i < 3; i++ {
+		SendPercent(ch, 3)
+	}
+	for i := 0; i < 3; i++ {
+		if total := <-ch; total != 3 {
+			t.Errorf("total = %d, want 3", total)
+		}
+	}
+}
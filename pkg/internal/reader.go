@@ -0,0 +1,265 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
+// ObjectFetcher 分块读取使用的客户端方法,v2、v4客户端均已实现
+type ObjectFetcher interface {
+	HeadWithContext(ctx context.Context, bucket, object string) (http.Header, error)
+	CatWithContext(ctx context.Context, bucket, object, partRange string, dsc io.Writer) (http.Header, error)
+}
+
+// ObjectReader 实现s3.ObjectReader
+// 按分块对齐发送Range请求,Read、ReadAt共用最近使用的分块,最多同时readahead个请求
+// 每个分块是一次独立的请求,连接中断时按重试策略重新请求该分块,分块失败后再次读取会重新请求
+type ObjectReader struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	client    ObjectFetcher
+	bucket    string
+	object    string
+	info      *s3.ObjectInfo
+	blockSize int64
+	readahead int64
+	sem       chan struct{}
+	progress  *Progress
+
+	offset int64
+	mu     sync.Mutex
+	blocks map[int64]*readBlock
+	tick   uint64
+	closed bool
+}
+
+type readBlock struct {
+	done     chan struct{}
+	data     []byte
+	err      error
+	lastUsed uint64
+}
+
+// NewObjectReader HEAD获取对象信息后创建分块读取,blockSize、readahead需已校验
+// Read时并发请求当前位置开始的readahead个分块
+func NewObjectReader(ctx context.Context, client ObjectFetcher, bucket, object string, opts *s3.GetOptions, blockSize, readahead int) (*ObjectReader, error) {
+	if opts == nil {
+		opts = &s3.GetOptions{}
+	}
+	header, err := client.HeadWithContext(ctx, bucket, object)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	r := &ObjectReader{
+		ctx:       ctx,
+		cancel:    cancel,
+		client:    client,
+		bucket:    bucket,
+		object:    object,
+		info:      NewObjectInfo(bucket, object, header),
+		blockSize: int64(blockSize),
+		readahead: int64(readahead),
+		sem:       make(chan struct{}, readahead),
+		progress:  NewProgress(opts.Progress),
+		blocks:    map[int64]*readBlock{},
+	}
+	r.progress.Start(r.info.Size, 0)
+	return r, nil
+}
+
+// Info 对象信息
+func (r *ObjectReader) Info() *s3.ObjectInfo {
+	return r.info
+}
+
+// Read 从当前位置读取,并预读之后的分块
+func (r *ObjectReader) Read(p []byte) (int, error) {
+	if r.offset >= r.info.Size {
+		if r.isClosed() {
+			return 0, io.ErrClosedPipe
+		}
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	index := r.offset / r.blockSize
+	last := (r.info.Size - 1) / r.blockSize
+	block, err := r.block(index)
+	if err != nil {
+		return 0, err
+	}
+	for i := index + 1; i < index+r.readahead && i <= last; i++ {
+		if _, err = r.block(i); err != nil {
+			return 0, err
+		}
+	}
+	data, err := r.wait(index, block)
+	if err != nil {
+		return 0, err
+	}
+	n := copy(p, data[r.offset-index*r.blockSize:])
+	r.offset += int64(n)
+	return n, nil
+}
+
+// block 返回index分块,不存在时发起请求,并丢弃最久未使用的分块
+func (r *ObjectReader) block(index int64) (*readBlock, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil, io.ErrClosedPipe
+	}
+	r.tick++
+	if block, ok := r.blocks[index]; ok {
+		block.lastUsed = r.tick
+		return block, nil
+	}
+	block := &readBlock{done: make(chan struct{}), lastUsed: r.tick}
+	r.blocks[index] = block
+	go func() {
+		defer close(block.done)
+		select {
+		case r.sem <- struct{}{}:
+		case <-r.ctx.Done():
+			block.err = r.ctx.Err()
+			return
+		}
+		defer func() {
+			<-r.sem
+		}()
+		block.data, block.err = r.fetch(r.ctx, index*r.blockSize, min((index+1)*r.blockSize, r.info.Size))
+		if block.err == nil {
+			r.progress.Transferred(int64(len(block.data)))
+		}
+	}()
+	//保留预读窗口两倍的分块
+	for int64(len(r.blocks)) > 2*r.readahead {
+		var oldest int64 = -1
+		for i, v := range r.blocks {
+			if oldest < 0 || v.lastUsed < r.blocks[oldest].lastUsed {
+				oldest = i
+			}
+		}
+		delete(r.blocks, oldest)
+	}
+	return block, nil
+}
+
+// wait 等待分块完成,失败的分块从缓存中删除以便重新请求
+func (r *ObjectReader) wait(index int64, block *readBlock) ([]byte, error) {
+	<-block.done
+	if block.err != nil {
+		r.mu.Lock()
+		if r.blocks[index] == block {
+			delete(r.blocks, index)
+		}
+		r.mu.Unlock()
+		return nil, block.err
+	}
+	return block.data, nil
+}
+
+// fetch 读取[start, end)范围的内容,对象在打开后被修改时返回错误
+func (r *ObjectReader) fetch(ctx context.Context, start, end int64) ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, end-start))
+	header, err := r.client.CatWithContext(ctx, r.bucket, r.object, fmt.Sprintf("bytes=%d-%d", start, end-1), buf)
+	if err != nil {
+		return nil, err
+	}
+	if etag := header.Get("Etag"); etag != "" && r.info.ETag != "" && etag != r.info.ETag {
+		return nil, fmt.Errorf(" Reader Object: %s Error: object changed, ETag %s != %s", r.object, etag, r.info.ETag)
+	}
+	if int64(buf.Len()) != end-start {
+		return nil, fmt.Errorf(" Reader Object: %s Error: %w", r.object, io.ErrUnexpectedEOF)
+	}
+	return buf.Bytes(), nil
+}
+
+// Seek 设置下次Read的位置,可超过对象大小
+func (r *ObjectReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.info.Size
+	default:
+		return 0, errors.New("ObjectReader.Seek: invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("ObjectReader.Seek: negative position")
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+// ReadAt 读取off开始的len(p)字节,可并发调用,不改变Read的位置
+func (r *ObjectReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("ObjectReader.ReadAt: negative offset")
+	}
+	if off >= r.info.Size {
+		if r.isClosed() {
+			return 0, io.ErrClosedPipe
+		}
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	end := min(off+int64(len(p)), r.info.Size)
+	first, last := off/r.blockSize, (end-1)/r.blockSize
+	blocks := make([]*readBlock, 0, last-first+1)
+	for i := first; i <= last; i++ {
+		block, err := r.block(i)
+		if err != nil {
+			return 0, err
+		}
+		blocks = append(blocks, block)
+	}
+	for k, block := range blocks {
+		index := first + int64(k)
+		data, err := r.wait(index, block)
+		if err != nil {
+			return 0, err
+		}
+		blockStart := index * r.blockSize
+		lo, hi := max(off, blockStart), min(end, blockStart+int64(len(data)))
+		copy(p[lo-off:hi-off], data[lo-blockStart:hi-blockStart])
+	}
+	n := int(end - off)
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (r *ObjectReader) isClosed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.closed
+}
+
+// Close 取消进行中的请求
+func (r *ObjectReader) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	r.blocks = nil
+	r.mu.Unlock()
+	r.cancel()
+	r.progress.Close(nil)
+	return nil
+}
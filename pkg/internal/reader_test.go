@@ -0,0 +1,252 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeFetcher 内存中的对象,记录收到的Range请求
+type fakeFetcher struct {
+	data []byte
+	etag string
+	// short 返回的内容比请求的范围少1字节
+	short bool
+	// block 不为nil时Cat等待block关闭或ctx取消
+	block chan struct{}
+
+	mu       sync.Mutex
+	ranges   []string
+	canceled int
+}
+
+func (f *fakeFetcher) HeadWithContext(ctx context.Context, bucket, object string) (http.Header, error) {
+	header := http.Header{}
+	header.Set("Content-Length", strconv.Itoa(len(f.data)))
+	header.Set("Etag", f.etag)
+	return header, nil
+}
+
+func (f *fakeFetcher) CatWithContext(ctx context.Context, bucket, object, partRange string, dsc io.Writer) (http.Header, error) {
+	f.mu.Lock()
+	f.ranges = append(f.ranges, partRange)
+	f.mu.Unlock()
+	if f.block != nil {
+		select {
+		case <-f.block:
+		case <-ctx.Done():
+			f.mu.Lock()
+			f.canceled++
+			f.mu.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+	var start, end int
+	if _, err := fmt.Sscanf(partRange, "bytes=%d-%d", &start, &end); err != nil {
+		return nil, err
+	}
+	end = min(end+1, len(f.data))
+	if f.short {
+		end--
+	}
+	_, _ = dsc.Write(f.data[start:end])
+	header := http.Header{}
+	header.Set("Etag", f.etag)
+	return header, nil
+}
+
+func (f *fakeFetcher) requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.ranges...)
+}
+
+func newTestReader(t *testing.T, f *fakeFetcher, blockSize, readahead int) *ObjectReader {
+	t.Helper()
+	r, err := NewObjectReader(context.Background(), f, "bucket", "key", nil, blockSize, readahead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+	return r
+}
+
+func TestObjectReaderRead(t *testing.T) {
+	f := &fakeFetcher{data: []byte("0123456789"), etag: `"etag"`}
+	r := newTestReader(t, f, 4, 1)
+	if r.Info().Size != 10 {
+		t.Fatalf("Size = %d", r.Info().Size)
+	}
+	// 每次Read最多读取到分块末尾
+	var got []byte
+	buf := make([]byte, 3)
+	for {
+		n, err := r.Read(buf)
+		got = append(got, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if string(got) != "0123456789" {
+		t.Errorf("Read = %q", got)
+	}
+	// 最后一个分块的范围只到对象末尾
+	want := []string{"bytes=0-3", "bytes=4-7", "bytes=8-9"}
+	if requests := f.requests(); fmt.Sprint(requests) != fmt.Sprint(want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+
+	// Seek到分块边界前,跨分块读取
+	if pos, err := r.Seek(-7, io.SeekEnd); err != nil || pos != 3 {
+		t.Fatalf("Seek = %d, %v", pos, err)
+	}
+	got, err := io.ReadAll(io.LimitReader(r, 6))
+	if err != nil || string(got) != "345678" {
+		t.Errorf("Read after Seek = %q, %v", got, err)
+	}
+	if pos, _ := r.Seek(0, io.SeekCurrent); pos != 9 {
+		t.Errorf("position = %d, want 9", pos)
+	}
+	if _, err = r.Seek(20, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := r.Read(buf); n != 0 || err != io.EOF {
+		t.Errorf("Read past end = %d, %v", n, err)
+	}
+	if _, err = r.Seek(-1, io.SeekStart); err == nil {
+		t.Error("Seek to negative position succeeded")
+	}
+	if _, err = r.Seek(0, 3); err == nil {
+		t.Error("Seek with invalid whence succeeded")
+	}
+}
+
+func TestObjectReaderReadAt(t *testing.T) {
+	f := &fakeFetcher{data: []byte("0123456789"), etag: `"etag"`}
+	r := newTestReader(t, f, 4, 2)
+	tests := []struct {
+		off  int64
+		size int
+		want string
+		err  error
+	}{
+		{0, 4, "0123", nil},
+		// 跨越两个分块
+		{2, 5, "23456", nil},
+		// 跨越全部分块
+		{0, 10, "0123456789", nil},
+		// 超过对象末尾
+		{8, 4, "89", io.EOF},
+		{10, 1, "", io.EOF},
+	}
+	for _, tt := range tests {
+		p := make([]byte, tt.size)
+		n, err := r.ReadAt(p, tt.off)
+		if string(p[:n]) != tt.want || err != tt.err {
+			t.Errorf("ReadAt(%d, %d) = %q, %v, want %q, %v", tt.off, tt.size, p[:n], err, tt.want, tt.err)
+		}
+	}
+	if _, err := r.ReadAt(make([]byte, 1), -1); err == nil {
+		t.Error("ReadAt negative offset succeeded")
+	}
+	// 分块已缓存,不再重复请求
+	if requests := f.requests(); len(requests) != 3 {
+		t.Errorf("requests = %v, want 3 blocks", requests)
+	}
+	// ReadAt不改变Read的位置
+	buf := make([]byte, 2)
+	if n, err := r.Read(buf); err != nil || string(buf[:n]) != "01" {
+		t.Errorf("Read after ReadAt = %q, %v", buf[:n], err)
+	}
+}
+
+func TestObjectReaderReadAtEmpty(t *testing.T) {
+	f := &fakeFetcher{data: []byte("0123456789")}
+	r := newTestReader(t, f, 4, 1)
+	if n, err := r.ReadAt(nil, 5); n != 0 || err != nil {
+		t.Errorf("ReadAt(nil, 5) = %d, %v, want 0, nil", n, err)
+	}
+	if n, err := r.Read(nil); n != 0 || err != nil {
+		t.Errorf("Read(nil) = %d, %v, want 0, nil", n, err)
+	}
+	if requests := f.requests(); len(requests) != 0 {
+		t.Errorf("requests = %v, want none", requests)
+	}
+}
+
+func TestObjectReaderShortRange(t *testing.T) {
+	f := &fakeFetcher{data: []byte("0123456789"), short: true}
+	r := newTestReader(t, f, 4, 1)
+	if _, err := r.Read(make([]byte, 4)); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Read short range err = %v, want ErrUnexpectedEOF", err)
+	}
+	// 失败的分块再次读取时重新请求
+	f.short = false
+	buf := make([]byte, 4)
+	if n, err := r.Read(buf); err != nil || string(buf[:n]) != "0123" {
+		t.Errorf("Read after failure = %q, %v", buf[:n], err)
+	}
+	if requests := f.requests(); len(requests) != 2 {
+		t.Errorf("requests = %v, want 2", requests)
+	}
+}
+
+func TestObjectReaderChanged(t *testing.T) {
+	f := &fakeFetcher{data: []byte("0123456789"), etag: `"v1"`}
+	r := newTestReader(t, f, 4, 1)
+	f.etag = `"v2"`
+	if _, err := r.ReadAt(make([]byte, 4), 0); err == nil {
+		t.Error("ReadAt of a changed object succeeded")
+	}
+}
+
+func TestObjectReaderCloseCancelsReadahead(t *testing.T) {
+	f := &fakeFetcher{data: bytes.Repeat([]byte("x"), 16), block: make(chan struct{})}
+	r := newTestReader(t, f, 4, 3)
+	done := make(chan error, 1)
+	go func() {
+		_, err := r.Read(make([]byte, 4))
+		done <- err
+	}()
+	// 等待当前分块及预读的分块都已发出
+	deadline := time.Now().Add(time.Second)
+	for len(f.requests()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if requests := f.requests(); len(requests) != 3 {
+		t.Fatalf("requests = %v, want 3 with readahead", requests)
+	}
+	_ = r.Close()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Read err = %v, want context.Canceled", err)
+	}
+	deadline = time.Now().Add(time.Second)
+	for {
+		f.mu.Lock()
+		canceled := f.canceled
+		f.mu.Unlock()
+		if canceled == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("canceled = %d, want 3", canceled)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := r.Read(make([]byte, 4)); err != io.ErrClosedPipe {
+		t.Errorf("Read after Close err = %v, want ErrClosedPipe", err)
+	}
+	if _, err := r.ReadAt(make([]byte, 4), 0); err != io.ErrClosedPipe {
+		t.Errorf("ReadAt after Close err = %v, want ErrClosedPipe", err)
+	}
+}
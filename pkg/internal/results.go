@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
+// NewObjectInfo 根据HEAD/GET响应头生成对象信息
+func NewObjectInfo(bucket, key string, header http.Header) *s3.ObjectInfo {
+	size, _ := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	lastModified, _ := http.ParseTime(header.Get("Last-Modified"))
+	return &s3.ObjectInfo{
+		Bucket:       bucket,
+		Key:          key,
+		Size:         size,
+		ETag:         header.Get("Etag"),
+		ContentType:  header.Get("Content-Type"),
+		LastModified: lastModified,
+	}
+}
+
+// PartInfo 分块上传中已上传的分块
+type PartInfo struct {
+	PartNumber int
+	Size       int64
+	ETag       string
+	// ChecksumAlgorithm、Checksum 分块的附加校验和,未使用时为空
+	ChecksumAlgorithm s3.ChecksumAlgorithm
+	Checksum          string
+}
+
+// NewPartInfos 转换已上传分块列表结果,result为nil时返回nil
+func NewPartInfos(result *s3.ListUploadPartsResult) []PartInfo {
+	if result == nil {
+		return nil
+	}
+	parts := make([]PartInfo, 0, len(result.Part))
+	for _, v := range result.Part {
+		algorithm, checksum := PartChecksum(v.PartChecksum)
+		parts = append(parts, PartInfo{PartNumber: v.PartNumber, Size: v.Size, ETag: v.ETag, ChecksumAlgorithm: algorithm, Checksum: checksum})
+	}
+	return parts
+}
+
+// BulkCounter 批量操作的并发安全统计
+type BulkCounter struct {
+	mu     sync.Mutex
+	start  time.Time
+	result s3.BulkResult
+}
+
+// NewBulkCounter 创建统计,从创建时开始计时
+func NewBulkCounter() *BulkCounter {
+	return &BulkCounter{start: time.Now()}
+}
+
+// Skip 记录跳过的对象
+func (b *BulkCounter) Skip(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.result.Skip++
+	b.result.Skipped = append(b.result.Skipped, key)
+}
+
+// Finish 记录完成的对象数量及大小
+func (b *BulkCounter) Finish(n int, size int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.result.Finish += n
+	b.result.Size += size
+}
+
+// Fail 记录失败的对象
+func (b *BulkCounter) Fail(key string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.result.Failed = append(b.result.Failed, s3.BulkError{Key: key, Err: err})
+}
+
+// Result 返回统计结果
+func (b *BulkCounter) Result(total int) *s3.BulkResult {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	result := b.result
+	result.Total = total
+	result.Duration = time.Since(b.start)
+	return &result
+}
+
+// ContentsSize 列表中对象的总大小
+func ContentsSize(contents []s3.ListObjectContents) int64 {
+	var size int64
+	for _, v := range contents {
+		size += int64(v.Size)
+	}
+	return size
+}
@@ -0,0 +1,187 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
+// 用于判断错误码的响应体上限
+const maxErrorBodySize = 1 << 20
+
+// Retry-After的上限,防止异常的值溢出或长时间等待,重试策略可进一步限制
+const maxRetryAfter = time.Hour
+
+// retryBudget 客户端共享的重试额度,请求占用的额度在请求结束时归还
+type retryBudget struct {
+	mu       sync.Mutex
+	tokens   int
+	capacity int
+}
+
+func newRetryBudget(capacity int) *retryBudget {
+	if capacity <= 0 {
+		return nil
+	}
+	return &retryBudget{tokens: capacity, capacity: capacity}
+}
+
+// acquire 占用一次重试额度,额度不足时返回false
+func (b *retryBudget) acquire() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens <= 0 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// release 归还重试额度
+func (b *retryBudget) release(n int) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens += n
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+}
+
+// retryAttempt 根据请求结果生成重试信息,请求成功时返回nil
+func retryAttempt(attempt int, resp *http.Response, errBody []byte, err error) *s3.RetryAttempt {
+	if err != nil {
+		return &s3.RetryAttempt{Attempt: attempt, Err: err}
+	}
+	if resp.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+	var errorMsg = &s3.Error{}
+	_ = xml.Unmarshal(errBody, errorMsg)
+	return &s3.RetryAttempt{
+		Attempt:    attempt,
+		StatusCode: resp.StatusCode,
+		Code:       errorMsg.Code,
+		RetryAfter: retryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// retryAfter 解析Retry-After,支持秒数和http时间,最多返回maxRetryAfter
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		if seconds > int64(maxRetryAfter/time.Second) {
+			return maxRetryAfter
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return min(d, maxRetryAfter)
+		}
+	}
+	return 0
+}
+
+// bodyRewinder 返回将请求体恢复到当前位置的函数,无法恢复时返回nil
+func bodyRewinder(body io.Reader) func() error {
+	if body == nil {
+		return func() error { return nil }
+	}
+	seeker, ok := body.(io.Seeker)
+	if !ok {
+		return nil
+	}
+	offset, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil
+	}
+	return func() error {
+		_, err := seeker.Seek(offset, io.SeekStart)
+		return err
+	}
+}
+
+// writerRewinder 返回将dsc恢复到当前写入位置的函数,无法恢复时返回nil
+func writerRewinder(dsc io.Writer) func() error {
+	switch w := dsc.(type) {
+	case nil:
+		return func() error { return nil }
+	case *bytes.Buffer:
+		size := w.Len()
+		return func() error {
+			w.Truncate(size)
+			return nil
+		}
+	case *ChecksumWriter:
+		return writerRewinder(w.w)
+	case *progressWriter:
+		rewind := writerRewinder(w.w)
+		if rewind == nil {
+			return nil
+		}
+		written := w.written
+		return func() error {
+			w.written = written
+			return rewind()
+		}
+	case *os.File:
+		offset, err := w.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil
+		}
+		return func() error {
+			if err := w.Truncate(offset); err != nil {
+				return err
+			}
+			_, err := w.Seek(offset, io.SeekStart)
+			return err
+		}
+	}
+	return nil
+}
+
+// countWriter 统计写入的字节数
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// sleep 等待重试,ctx取消时提前返回
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"", 0, 0},
+		{"3", 3 * time.Second, 3 * time.Second},
+		{"0", 0, 0},
+		{"-1", 0, 0},
+		{"abc", 0, 0},
+		{"99999999999999", maxRetryAfter, maxRetryAfter},
+		{time.Now().Add(48 * time.Hour).UTC().Format(http.TimeFormat), maxRetryAfter, maxRetryAfter},
+		{time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat), 8 * time.Second, 10 * time.Second},
+		{time.Now().Add(-10 * time.Second).UTC().Format(http.TimeFormat), 0, 0},
+	}
+	for _, tt := range tests {
+		if got := retryAfter(tt.value); got < tt.min || got > tt.max {
+			t.Errorf("retryAfter(%q) = %v, want [%v, %v]", tt.value, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestRetryAttempt(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{"Retry-After": {"2"}}}
+	body := []byte(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>SlowDown</Code><Message>Please reduce your request rate.</Message></Error>`)
+	got := retryAttempt(1, resp, body, nil)
+	if got == nil || got.Attempt != 1 || got.StatusCode != http.StatusServiceUnavailable || got.Code != "SlowDown" || got.RetryAfter != 2*time.Second {
+		t.Errorf("retryAttempt = %+v", got)
+	}
+	if got := retryAttempt(0, &http.Response{StatusCode: http.StatusOK}, nil, nil); got != nil {
+		t.Errorf("retryAttempt(200) = %+v, want nil", got)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	if newRetryBudget(0) != nil {
+		t.Fatal("newRetryBudget(0) should be unlimited")
+	}
+	var unlimited *retryBudget
+	if !unlimited.acquire() {
+		t.Fatal("nil budget acquire = false")
+	}
+	b := newRetryBudget(2)
+	if !b.acquire() || !b.acquire() {
+		t.Fatal("acquire within capacity = false")
+	}
+	if b.acquire() {
+		t.Fatal("acquire over capacity = true")
+	}
+	// 未占用额度的请求不归还
+	b.release(0)
+	if b.acquire() {
+		t.Fatal("release(0) refilled the budget")
+	}
+	b.release(5)
+	if b.tokens != b.capacity {
+		t.Errorf("tokens = %d, want capacity %d", b.tokens, b.capacity)
+	}
+}
+
+// testRetryPolicy 固定等待时间的重试策略
+type testRetryPolicy struct {
+	delay time.Duration
+	max   int
+}
+
+func (p *testRetryPolicy) Retry(attempt *s3.RetryAttempt) (time.Duration, bool) {
+	return p.delay, attempt.Attempt < p.max
+}
+
+func TestCURLRetryBudget(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	policy := &testRetryPolicy{max: 5}
+	c := NewClient(&s3.Config{HTTPClient: srv.Client(), RetryPolicy: policy, RetryBudget: 2})
+
+	fail := func(ctx context.Context) (int32, error) {
+		requests.Store(0)
+		_, _, err := c.CURL(ctx, srv.URL, "GET", nil, strings.NewReader(""), nil)
+		return requests.Load(), err
+	}
+	// 额度用完时不再重试,失败的请求结束后归还额度,之后的请求仍可重试
+	for i := 0; i < 3; i++ {
+		if n, err := fail(context.Background()); err != nil || n != 3 {
+			t.Fatalf("round %d: requests = %d, err = %v, want 3 requests", i, n, err)
+		}
+	}
+	// 等待重试时ctx超时也归还额度
+	policy.delay = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := fail(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want DeadlineExceeded", err)
+	}
+	policy.delay = 0
+	if n, _ := fail(context.Background()); n != 3 {
+		t.Errorf("requests after ctx timeout = %d, want 3", n)
+	}
+	if c.budget.tokens != c.budget.capacity {
+		t.Errorf("tokens = %d, want %d", c.budget.tokens, c.budget.capacity)
+	}
+}
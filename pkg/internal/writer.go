@@ -0,0 +1,244 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
+// MaxPartNum 分块上传的最大分块数
+const MaxPartNum = 10000
+
+// MultipartUploader 流式上传使用的客户端方法,v2、v4客户端均已实现
+type MultipartUploader interface {
+	PutWithOptions(ctx context.Context, body io.Reader, bodySize int, bucket, object string, opts *s3.PutOptions) (*s3.PutResult, error)
+	InitUploadWithOptions(ctx context.Context, bucket, object string, opts *s3.PutOptions) (*s3.InitUploadResult, error)
+	UploadPartWithOptions(ctx context.Context, content io.Reader, bodySize int, bucket, object string, partNumber int, uploadID string, opts *s3.PartOptions) (http.Header, error)
+	CompleteUploadWithOptions(ctx context.Context, body []byte, bucket, object, uploadID string, opts *s3.CompleteOptions) (*s3.PutResult, error)
+	CancelPartWithContext(ctx context.Context, bucket, object string, uploadID string) (http.Header, error)
+}
+
+// ObjectWriter 实现s3.ObjectWriter
+// 数据按partSize缓冲,超过一个分块时初始化分块上传并最多threadNum个分块并发上传,否则Close时使用Put上传
+type ObjectWriter struct {
+	ctx      context.Context
+	client   MultipartUploader
+	bucket   string
+	object   string
+	opts     *s3.MultipartOptions
+	partSize int
+	sem      chan struct{}
+	progress *Progress
+	start    time.Time
+
+	buf      []byte
+	size     int64
+	partNum  int
+	uploadID string
+	wg       sync.WaitGroup
+
+	mu        sync.Mutex
+	etags     []string
+	checksums []string
+	err       error
+
+	closed   bool
+	closeErr error
+	result   *s3.PutResult
+}
+
+// NewObjectWriter 创建流式上传,partSize、threadNum需已校验
+func NewObjectWriter(ctx context.Context, client MultipartUploader, bucket, object string, opts *s3.MultipartOptions, partSize, threadNum int) *ObjectWriter {
+	if opts == nil {
+		opts = &s3.MultipartOptions{}
+	}
+	w := &ObjectWriter{
+		ctx:      ctx,
+		client:   client,
+		bucket:   bucket,
+		object:   object,
+		opts:     opts,
+		partSize: partSize,
+		sem:      make(chan struct{}, threadNum),
+		progress: NewProgress(opts.Progress),
+		start:    time.Now(),
+	}
+	w.progress.Start(0, 0)
+	return w
+}
+
+// Write 缓冲数据,缓冲满一个分块且有后续数据时上传该分块,分块上传失败后返回该错误
+func (w *ObjectWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		if w.closeErr != nil {
+			return 0, w.closeErr
+		}
+		return 0, io.ErrClosedPipe
+	}
+	if err := w.failed(); err != nil {
+		return 0, err
+	}
+	var n int
+	for len(p) > 0 {
+		if len(w.buf) == w.partSize {
+			if err := w.flush(); err != nil {
+				return n, err
+			}
+		}
+		if w.buf == nil {
+			w.buf = make([]byte, 0, w.partSize)
+		}
+		m := copy(w.buf[len(w.buf):w.partSize], p)
+		w.buf = w.buf[:len(w.buf)+m]
+		w.size += int64(m)
+		n += m
+		p = p[m:]
+	}
+	return n, nil
+}
+
+// flush 上传缓冲的分块,并发数已满时等待
+func (w *ObjectWriter) flush() error {
+	if w.uploadID == "" {
+		initUpload, err := w.client.InitUploadWithOptions(w.ctx, w.bucket, w.object, &s3.PutOptions{ACL: w.opts.ACL, Disposition: w.opts.Disposition})
+		if err != nil {
+			w.fail(err)
+			return err
+		}
+		w.uploadID = initUpload.UploadID
+	}
+	if w.partNum == MaxPartNum {
+		err := fmt.Errorf(" Writer Object: %s Error: more than %d parts, increase PartSize", w.object, MaxPartNum)
+		w.fail(err)
+		return err
+	}
+	select {
+	case w.sem <- struct{}{}:
+	case <-w.ctx.Done():
+		w.fail(w.ctx.Err())
+		return w.ctx.Err()
+	}
+	if err := w.failed(); err != nil {
+		<-w.sem
+		return err
+	}
+	w.partNum++
+	part := w.buf
+	w.buf = nil
+	w.mu.Lock()
+	w.etags = append(w.etags, "")
+	w.checksums = append(w.checksums, "")
+	w.mu.Unlock()
+	w.progress.AddTotal(int64(len(part)), 0)
+	w.wg.Add(1)
+	go func(partNum int, part []byte) {
+		defer func() {
+			<-w.sem
+			w.wg.Done()
+		}()
+		header, err := w.client.UploadPartWithOptions(w.ctx, bytes.NewReader(part), len(part), w.bucket, w.object, partNum, w.uploadID, &s3.PartOptions{Progress: w.progress})
+		if err != nil {
+			w.fail(err)
+			return
+		}
+		w.mu.Lock()
+		w.etags[partNum-1] = header.Get("Etag")
+		w.checksums[partNum-1] = ChecksumElement(ResponseChecksum(header))
+		w.mu.Unlock()
+	}(w.partNum, part)
+	return nil
+}
+
+// fail 记录第一个错误
+func (w *ObjectWriter) fail(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+func (w *ObjectWriter) failed() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Close 上传剩余数据并完成上传,失败时取消分块上传
+func (w *ObjectWriter) Close() (err error) {
+	if w.closed {
+		return w.closeErr
+	}
+	w.closed = true
+	defer func() {
+		w.closeErr = err
+		w.progress.Close(err)
+	}()
+	if w.uploadID == "" && w.failed() == nil {
+		w.progress.AddTotal(int64(len(w.buf)), 0)
+		result, err := w.client.PutWithOptions(w.ctx, bytes.NewReader(w.buf), len(w.buf), w.bucket, w.object, &s3.PutOptions{ACL: w.opts.ACL, Disposition: w.opts.Disposition, Progress: w.progress})
+		if err != nil {
+			return err
+		}
+		result.Duration = time.Since(w.start)
+		w.result = result
+		return nil
+	}
+	if len(w.buf) > 0 && w.failed() == nil {
+		_ = w.flush()
+	}
+	w.wg.Wait()
+	if err = w.failed(); err != nil {
+		w.abort()
+		return err
+	}
+	completeUploadInfo := "<CompleteMultipartUpload>"
+	for partNum, Etag := range w.etags {
+		completeUploadInfo += fmt.Sprintf("<Part><PartNumber>%d</PartNumber><ETag>%s</ETag>%s</Part>", partNum+1, Etag, w.checksums[partNum])
+	}
+	completeUploadInfo += "</CompleteMultipartUpload>"
+	result, err := w.client.CompleteUploadWithOptions(w.ctx, []byte(completeUploadInfo), w.bucket, w.object, w.uploadID, &s3.CompleteOptions{ObjectSize: w.size})
+	if err != nil {
+		w.abort()
+		return err
+	}
+	result.Duration = time.Since(w.start)
+	w.result = result
+	return nil
+}
+
+// CloseWithError 等待上传中的分块结束后取消分块上传,返回取消的错误
+func (w *ObjectWriter) CloseWithError(err error) error {
+	if w.closed {
+		return nil
+	}
+	if err == nil {
+		err = io.ErrClosedPipe
+	}
+	w.closed = true
+	w.closeErr = err
+	w.fail(err)
+	w.wg.Wait()
+	w.progress.Close(err)
+	return w.abort()
+}
+
+// abort 取消分块上传,ctx已取消时仍然发送取消请求
+func (w *ObjectWriter) abort() error {
+	if w.uploadID == "" {
+		return nil
+	}
+	_, err := w.client.CancelPartWithContext(context.WithoutCancel(w.ctx), w.bucket, w.object, w.uploadID)
+	return err
+}
+
+// Result Close成功后返回上传结果
+func (w *ObjectWriter) Result() *s3.PutResult {
+	return w.result
+}
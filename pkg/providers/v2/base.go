@@ -1,6 +1,7 @@
 package v2
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha1"
 	"sort"
@@ -9,7 +10,15 @@ import (
 	"github.com/shideqin/go-s3-sdk/pkg/internal"
 )
 
-func (c *Client) sign(method string, headers map[string]string, bucket, object string) string {
+func (c *Client) sign(ctx context.Context, method string, headers map[string]string, bucket, object string) (string, error) {
+	creds, err := c.credentials.Retrieve(ctx)
+	if err != nil {
+		return "", err
+	}
+	//STS临时凭证的token作为x-amz-头参与签名
+	if creds.SessionToken != "" {
+		headers["x-amz-security-token"] = creds.SessionToken
+	}
 	var keyList []string
 	LF := "\n"
 	sign := method + LF
@@ -31,7 +40,7 @@ func (c *Client) sign(method string, headers map[string]string, bucket, object s
 	if object != "" {
 		sign += "/" + object
 	}
-	return "AWS " + c.accessKeyID + ":" + internal.Base64Encode([]byte(hmacEncode(sign, c.accessKeySecret)))
+	return "AWS " + creds.AccessKeyID + ":" + internal.Base64Encode([]byte(hmacEncode(sign, creds.SecretAccessKey))), nil
 }
 
 func hmacEncode(sign, key string) string {
@@ -2,13 +2,13 @@ package v2
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/shideqin/go-s3-sdk/pkg/internal"
@@ -29,25 +29,31 @@ type ListPartsResult = s3.ListPartsResult
 
 // GetService 获取bucket列表
 func (c *Client) GetService() (*ServiceResult, error) {
-	addr := fmt.Sprintf("http://%s/", c.host)
+	return c.GetServiceWithContext(context.Background())
+}
+
+// GetServiceWithContext 获取bucket列表,支持通过ctx取消和设置超时
+func (c *Client) GetServiceWithContext(ctx context.Context) (*ServiceResult, error) {
+	addr := c.endpoint.URL("", "")
 	method := "GET"
 	date := time.Unix(time.Now().Unix()-8*3600, 0).Format(c.dateTimeGMT)
 	headers := map[string]string{
 		"Date": date,
 	}
 	LF := "\n"
-	headers["Authorization"] = c.sign(method+LF+LF, headers, "", "")
+	auth, err := c.sign(ctx, method+LF+LF, headers, "", "")
+	if err != nil {
+		return nil, fmt.Errorf(" GetService Error: %v", err)
+	}
+	headers["Authorization"] = auth
 	body := &bytes.Buffer{}
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(""), body, nil)
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), body)
 	if err != nil {
 		return nil, fmt.Errorf(" GetService Error: %v", err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" GetService StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", status, reqID, errorMsg.Code, errorMsg.Message)
+		return nil, internal.NewResponseError("GetService", "", "", header, errBody)
 	}
 	var service = &ServiceResult{}
 	if err = xml.Unmarshal(body.Bytes(), service); err != nil {
@@ -58,92 +64,129 @@ func (c *Client) GetService() (*ServiceResult, error) {
 
 // CreateBucket 创建bucket
 func (c *Client) CreateBucket(bucket string, options map[string]string) (http.Header, error) {
-	addr := fmt.Sprintf("http://%s.%s/", bucket, c.host)
+	return c.CreateBucketWithContext(context.Background(), bucket, options)
+}
+
+// CreateBucketWithContext 创建bucket,支持通过ctx取消和设置超时
+func (c *Client) CreateBucketWithContext(ctx context.Context, bucket string, options map[string]string) (http.Header, error) {
+	return c.CreateBucketWithOptions(ctx, bucket, internal.BucketOptions(options))
+}
+
+// CreateBucketWithOptions 创建bucket,参数非法时返回s3.ErrInvalidOption
+func (c *Client) CreateBucketWithOptions(ctx context.Context, bucket string, opts *s3.BucketOptions) (http.Header, error) {
+	if opts == nil {
+		opts = &s3.BucketOptions{}
+	}
+	addr := c.endpoint.URL(bucket, "")
 	method := "PUT"
 	date := time.Unix(time.Now().Unix()-8*3600, 0).Format(c.dateTimeGMT)
 	headers := map[string]string{
 		"Date": date,
 	}
-	if options["acl"] != "" {
-		headers["x-amz-acl"] = options["acl"]
+	if opts.ACL != "" {
+		headers["x-amz-acl"] = opts.ACL
 	}
 	LF := "\n"
-	headers["Authorization"] = c.sign(method+LF+LF, headers, bucket+"/", "")
+	auth, err := c.sign(ctx, method+LF+LF, headers, bucket+"/", "")
+	if err != nil {
+		return nil, fmt.Errorf(" CreateBucket Bucket: %s Error: %v", bucket, err)
+	}
+	headers["Authorization"] = auth
 	body := &bytes.Buffer{}
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(""), body, nil)
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), body)
 	if err != nil {
 		return nil, fmt.Errorf(" CreateBucket Bucket: %s Error: %v", bucket, err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" CreateBucket Bucket: %s StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", bucket, status, reqID, errorMsg.Code, errorMsg.Message)
+		return nil, internal.NewResponseError("CreateBucket", bucket, "", header, errBody)
 	}
 	return header, nil
 }
 
 // DeleteBucket 删除bucket
 func (c *Client) DeleteBucket(bucket string) (http.Header, error) {
-	addr := fmt.Sprintf("http://%s.%s/", bucket, c.host)
+	return c.DeleteBucketWithContext(context.Background(), bucket)
+}
+
+// DeleteBucketWithContext 删除bucket,支持通过ctx取消和设置超时
+func (c *Client) DeleteBucketWithContext(ctx context.Context, bucket string) (http.Header, error) {
+	addr := c.endpoint.URL(bucket, "")
 	method := "DELETE"
 	date := time.Unix(time.Now().Unix()-8*3600, 0).Format(c.dateTimeGMT)
 	headers := map[string]string{
 		"Date": date,
 	}
 	LF := "\n"
-	headers["Authorization"] = c.sign(method+LF+LF, headers, bucket+"/", "")
+	auth, err := c.sign(ctx, method+LF+LF, headers, bucket+"/", "")
+	if err != nil {
+		return nil, fmt.Errorf(" DeleteBucket Bucket: %s Error: %v", bucket, err)
+	}
+	headers["Authorization"] = auth
 	body := &bytes.Buffer{}
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(""), body, nil)
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), body)
 	if err != nil {
 		return nil, fmt.Errorf(" DeleteBucket Bucket: %s Error: %v", bucket, err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
-	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" DeleteBucket Bucket: %s StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", bucket, status, reqID, errorMsg.Code, errorMsg.Message)
+	if status != "200" && status != "204" {
+		return nil, internal.NewResponseError("DeleteBucket", bucket, "", header, errBody)
 	}
 	return header, nil
 }
 
 // ListPart 查看分块列表
 func (c *Client) ListPart(bucket string, options map[string]string) (*ListPartsResult, error) {
+	return c.ListPartWithContext(context.Background(), bucket, options)
+}
+
+// ListPartWithContext 查看分块列表,支持通过ctx取消和设置超时
+func (c *Client) ListPartWithContext(ctx context.Context, bucket string, options map[string]string) (*ListPartsResult, error) {
+	return c.ListPartWithOptions(ctx, bucket, internal.ListPartOptions(options))
+}
+
+// ListPartWithOptions 查看分块列表,参数非法时返回s3.ErrInvalidOption
+func (c *Client) ListPartWithOptions(ctx context.Context, bucket string, opts *s3.ListPartOptions) (*ListPartsResult, error) {
+	if opts == nil {
+		opts = &s3.ListPartOptions{}
+	}
 	param := ""
-	if options["delimiter"] != "" {
-		param += "&delimiter=" + options["delimiter"]
+	if opts.Delimiter != "" {
+		param += "&delimiter=" + opts.Delimiter
 	}
-	if options["key-marker"] != "" {
-		param += "&key-marker=" + options["key-marker"]
+	if opts.KeyMarker != "" {
+		param += "&key-marker=" + opts.KeyMarker
 	}
-	if options["max-keys"] != "" {
-		param += "&max-keys=" + options["max-keys"]
+	if opts.MaxUploads < 0 {
+		return nil, fmt.Errorf(" ListPart Bucket: %s Error: %w: MaxUploads %d must not be negative", bucket, s3.ErrInvalidOption, opts.MaxUploads)
 	}
-	if options["prefix"] != "" {
-		param += "&prefix=" + options["prefix"]
+	if opts.MaxUploads > 0 {
+		param += "&max-keys=" + strconv.Itoa(opts.MaxUploads)
+	}
+	if opts.Prefix != "" {
+		param += "&prefix=" + opts.Prefix
 	}
 	subObject := "/?uploads"
-	addr := fmt.Sprintf("http://%s.%s%s%s", bucket, c.host, subObject, param)
+	addr := c.endpoint.URL(bucket, "") + strings.TrimPrefix(subObject, "/") + param
 	method := "GET"
 	date := time.Unix(time.Now().Unix()-8*3600, 0).Format(c.dateTimeGMT)
 	headers := map[string]string{
 		"Date": date,
 	}
 	LF := "\n"
-	headers["Authorization"] = c.sign(method+LF+LF, headers, bucket+subObject, "")
+	auth, err := c.sign(ctx, method+LF+LF, headers, bucket+subObject, "")
+	if err != nil {
+		return nil, fmt.Errorf(" ListPart Bucket: %s Error: %v", bucket, err)
+	}
+	headers["Authorization"] = auth
 	body := &bytes.Buffer{}
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(""), body, nil)
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), body)
 	if err != nil {
 		return nil, fmt.Errorf(" ListPart Bucket: %s Error: %v", bucket, err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" ListPart Bucket: %s StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", bucket, status, reqID, errorMsg.Code, errorMsg.Message)
+		return nil, internal.NewResponseError("ListPart", bucket, "", header, errBody)
 	}
 	var ListParts = &ListPartsResult{}
 	if err = xml.Unmarshal(body.Bytes(), ListParts); err != nil {
@@ -154,29 +197,59 @@ func (c *Client) ListPart(bucket string, options map[string]string) (*ListPartsR
 
 // DeleteAllPart 删除所有分块
 func (c *Client) DeleteAllPart(bucket, prefix string, options map[string]string, percentChan chan int) (map[string]int, error) {
+	return c.DeleteAllPartWithContext(context.Background(), bucket, prefix, options, percentChan)
+}
+
+// DeleteAllPartWithContext 删除所有分块,支持通过ctx取消和设置超时
+func (c *Client) DeleteAllPartWithContext(ctx context.Context, bucket, prefix string, options map[string]string, percentChan chan int) (map[string]int, error) {
+	result, err := c.deleteAllPart(ctx, bucket, prefix, c.limits.BulkOptions(options), percentChan)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// DeleteAllPartWithOptions 删除所有分块,参数非法时返回s3.ErrInvalidOption
+func (c *Client) DeleteAllPartWithOptions(ctx context.Context, bucket, prefix string, opts *s3.BulkOptions) (*s3.BulkResult, error) {
+	return c.deleteAllPart(ctx, bucket, prefix, opts, nil)
+}
+
+func (c *Client) deleteAllPart(ctx context.Context, bucket, prefix string, opts *s3.BulkOptions, percentChan chan int) (result *s3.BulkResult, err error) {
+	if opts == nil {
+		opts = &s3.BulkOptions{}
+	}
+	progress := internal.NewProgress(opts.Progress)
+	defer func() {
+		progress.Close(err)
+	}()
+	threadNum, err := c.limits.ThreadNum(opts.ThreadNum)
+	if err != nil {
+		return nil, fmt.Errorf(" DeleteAllPart Error: %w", err)
+	}
 	contents := make([]map[string]string, 0)
-	maxKeys := "1000"
-	if options["max-keys"] != "" {
-		maxKeys = options["max-keys"]
+	maxKeys := opts.MaxKeys
+	if maxKeys == 0 {
+		maxKeys = 1000
 	}
 	marker := ""
 	total := 0
-	var tmpFinish int64
-	var tmpSkip int64
+	counter := internal.NewBulkCounter()
+	progress.Start(0, 0)
 LIST:
-	list, err := c.ListPart(bucket, map[string]string{"prefix": prefix, "key-marker": marker, "max-keys": maxKeys})
+	list, err := c.ListPartWithOptions(ctx, bucket, &s3.ListPartOptions{Prefix: prefix, KeyMarker: marker, MaxUploads: maxKeys})
 	if err != nil {
-		return nil, err
+		return counter.Result(total), err
 	}
 	total += len(list.Upload)
+	progress.AddTotal(0, len(list.Upload))
 	if total <= 0 {
-		return map[string]int{"Total": 0, "Finish": 0}, nil
+		return counter.Result(total), nil
 	}
-	expired, _ := strconv.Atoi(options["expired"])
 	for _, v := range list.Upload {
 		lastModified, err := time.Parse("2006-01-02T15:04:05.000Z", v.Initiated)
-		if err == nil && time.Since(lastModified).Seconds() < float64(expired) {
-			atomic.AddInt64(&tmpSkip, 1)
+		if err == nil && time.Since(lastModified) < opts.Expired {
+			counter.Skip(v.Key)
+			progress.FileSkipped(v.Key, 0)
 			continue
 		}
 		contents = append(contents, map[string]string{"Bucket": bucket, "Key": v.Key, "UploadID": v.UploadID})
@@ -186,13 +259,6 @@ LIST:
 		goto LIST
 	}
 
-	var threadNum = c.threadMaxNum
-	if options["thread_num"] != "" {
-		n, _ := strconv.Atoi(options["thread_num"])
-		if n <= c.threadMaxNum && n >= c.threadMinNum {
-			threadNum = n
-		}
-	}
 	var contentSize = len(contents)
 	if contentSize < threadNum {
 		threadNum = contentSize
@@ -203,6 +269,10 @@ LIST:
 	var partExit bool
 	var wg sync.WaitGroup
 	for partNum := 0; partNum < contentSize; partNum++ {
+		if ctx.Err() != nil {
+			partErr = ctx.Err()
+			break
+		}
 		if partExit {
 			break
 		}
@@ -216,54 +286,50 @@ LIST:
 				wg.Done()
 				<-queueMaxSize
 			}()
-			for i := 0; i < c.maxRetryNum; i++ {
-				_, partErr = c.CancelPart(body["Bucket"], body["Key"], body["UploadID"])
-				if partErr != nil {
-					continue
-				}
-				partErr = nil
-				break
-			}
-			if partErr != nil {
+			_, cErr := c.CancelPartWithContext(ctx, body["Bucket"], body["Key"], body["UploadID"])
+			if cErr != nil {
+				partErr = cErr
+				counter.Fail(body["Key"], cErr)
+				progress.FileFailed(body["Key"], cErr)
 				return
 			}
-			atomic.AddInt64(&tmpFinish, 1)
-			if percentChan != nil {
-				percentChan <- total
-			}
+			counter.Finish(1, 0)
+			progress.FileCompleted(body["Key"])
+			internal.SendPercent(percentChan, total)
 		}(partNum, contents[partNum])
 	}
 	wg.Wait()
-	if partErr != nil {
-		return nil, partErr
-	}
-	finish := int(atomic.LoadInt64(&tmpFinish))
-	skip := int(atomic.LoadInt64(&tmpSkip))
-	return map[string]int{"Total": total, "Finish": finish, "Skip": skip}, nil
+	return counter.Result(total), partErr
 }
 
 // GetACL 获取bucket acl
 func (c *Client) GetACL(bucket string) (*AclResult, error) {
+	return c.GetACLWithContext(context.Background(), bucket)
+}
+
+// GetACLWithContext 获取bucket acl,支持通过ctx取消和设置超时
+func (c *Client) GetACLWithContext(ctx context.Context, bucket string) (*AclResult, error) {
 	subObject := "?acl"
-	addr := fmt.Sprintf("http://%s.%s/%s", bucket, c.host, subObject)
+	addr := c.endpoint.URL(bucket, "") + subObject
 	method := "GET"
 	date := time.Unix(time.Now().Unix()-8*3600, 0).Format(c.dateTimeGMT)
 	headers := map[string]string{
 		"Date": date,
 	}
 	LF := "\n"
-	headers["Authorization"] = c.sign(method+LF+LF, headers, bucket+"/"+subObject, "")
+	auth, err := c.sign(ctx, method+LF+LF, headers, bucket+"/"+subObject, "")
+	if err != nil {
+		return nil, fmt.Errorf(" GetACL Bucket: %s Error: %v", bucket, err)
+	}
+	headers["Authorization"] = auth
 	body := &bytes.Buffer{}
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(""), body, nil)
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), body)
 	if err != nil {
 		return nil, fmt.Errorf(" GetACL Bucket: %s Error: %v", bucket, err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" GetACL Bucket: %s StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", bucket, status, reqID, errorMsg.Code, errorMsg.Message)
+		return nil, internal.NewResponseError("GetACL", bucket, "", header, errBody)
 	}
 	var acl = &AclResult{}
 	if err = xml.Unmarshal(body.Bytes(), acl); err != nil {
@@ -274,55 +340,75 @@ func (c *Client) GetACL(bucket string) (*AclResult, error) {
 
 // SetACL 设置bucket acl
 func (c *Client) SetACL(bucket string, options map[string]string) (http.Header, error) {
+	return c.SetACLWithContext(context.Background(), bucket, options)
+}
+
+// SetACLWithContext 设置bucket acl,支持通过ctx取消和设置超时
+func (c *Client) SetACLWithContext(ctx context.Context, bucket string, options map[string]string) (http.Header, error) {
+	return c.SetACLWithOptions(ctx, bucket, internal.BucketOptions(options))
+}
+
+// SetACLWithOptions 设置bucket acl,参数非法时返回s3.ErrInvalidOption
+func (c *Client) SetACLWithOptions(ctx context.Context, bucket string, opts *s3.BucketOptions) (http.Header, error) {
+	if opts == nil {
+		opts = &s3.BucketOptions{}
+	}
 	subObject := "?acl"
-	addr := fmt.Sprintf("http://%s.%s/%s", bucket, c.host, subObject)
+	addr := c.endpoint.URL(bucket, "") + subObject
 	method := "PUT"
 	date := time.Unix(time.Now().Unix()-8*3600, 0).Format(c.dateTimeGMT)
 	headers := map[string]string{
 		"Date": date,
 	}
-	if options["acl"] != "" {
-		headers["x-amz-acl"] = options["acl"]
+	if opts.ACL != "" {
+		headers["x-amz-acl"] = opts.ACL
 	}
 	LF := "\n"
-	headers["Authorization"] = c.sign(method+LF+LF, headers, bucket+"/"+subObject, "")
+	auth, err := c.sign(ctx, method+LF+LF, headers, bucket+"/"+subObject, "")
+	if err != nil {
+		return nil, fmt.Errorf(" SetACL Bucket: %s Error: %v", bucket, err)
+	}
+	headers["Authorization"] = auth
 	body := &bytes.Buffer{}
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(""), body, nil)
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), body)
 	if err != nil {
 		return nil, fmt.Errorf(" SetACL Bucket: %s Error: %v", bucket, err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" SetACL Bucket: %s StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", bucket, status, reqID, errorMsg.Code, errorMsg.Message)
+		return nil, internal.NewResponseError("SetACL", bucket, "", header, errBody)
 	}
 	return header, nil
 }
 
 // GetLifecycle 获取bucket lifecycle
 func (c *Client) GetLifecycle(bucket string) (*LifecycleResult, error) {
+	return c.GetLifecycleWithContext(context.Background(), bucket)
+}
+
+// GetLifecycleWithContext 获取bucket lifecycle,支持通过ctx取消和设置超时
+func (c *Client) GetLifecycleWithContext(ctx context.Context, bucket string) (*LifecycleResult, error) {
 	subObject := "?lifecycle"
-	addr := fmt.Sprintf("http://%s.%s/%s", bucket, c.host, subObject)
+	addr := c.endpoint.URL(bucket, "") + subObject
 	method := "GET"
 	date := time.Unix(time.Now().Unix()-8*3600, 0).Format(c.dateTimeGMT)
 	headers := map[string]string{
 		"Date": date,
 	}
 	LF := "\n"
-	headers["Authorization"] = c.sign(method+LF+LF, headers, bucket+"/"+subObject, "")
+	auth, err := c.sign(ctx, method+LF+LF, headers, bucket+"/"+subObject, "")
+	if err != nil {
+		return nil, fmt.Errorf(" GetLifecycle Bucket: %s Error: %v", bucket, err)
+	}
+	headers["Authorization"] = auth
 	body := &bytes.Buffer{}
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(""), body, nil)
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), body)
 	if err != nil {
 		return nil, fmt.Errorf(" GetLifecycle Bucket: %s Error: %v", bucket, err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" GetLifecycle Bucket: %s StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", bucket, status, reqID, errorMsg.Code, errorMsg.Message)
+		return nil, internal.NewResponseError("GetLifecycle", bucket, "", header, errBody)
 	}
 	var lifecycle = &LifecycleResult{}
 	if err = xml.Unmarshal(body.Bytes(), lifecycle); err != nil {
@@ -333,8 +419,24 @@ func (c *Client) GetLifecycle(bucket string) (*LifecycleResult, error) {
 
 // SetLifecycle 设置bucket Lifecycle
 func (c *Client) SetLifecycle(bucket string, options map[string]string) (http.Header, error) {
+	return c.SetLifecycleWithContext(context.Background(), bucket, options)
+}
+
+// SetLifecycleWithContext 设置bucket Lifecycle,支持通过ctx取消和设置超时
+func (c *Client) SetLifecycleWithContext(ctx context.Context, bucket string, options map[string]string) (http.Header, error) {
+	return c.SetLifecycleWithOptions(ctx, bucket, internal.LifecycleOptions(options))
+}
+
+// SetLifecycleWithOptions 设置bucket Lifecycle,参数非法时返回s3.ErrInvalidOption
+func (c *Client) SetLifecycleWithOptions(ctx context.Context, bucket string, opts *s3.LifecycleOptions) (http.Header, error) {
+	if opts == nil {
+		opts = &s3.LifecycleOptions{}
+	}
+	if opts.Expiration <= 0 {
+		return nil, fmt.Errorf(" SetLifecycle Bucket: %s Error: %w: Expiration %d must be positive", bucket, s3.ErrInvalidOption, opts.Expiration)
+	}
 	content := "<LifecycleConfiguration>"
-	lifecycle, lErr := c.GetLifecycle(bucket)
+	lifecycle, lErr := c.GetLifecycleWithContext(ctx, bucket)
 	if lErr == nil {
 		for _, v := range lifecycle.Rules {
 			content += "<Rule>"
@@ -348,11 +450,11 @@ func (c *Client) SetLifecycle(bucket string, options map[string]string) (http.He
 	content += "<Rule>"
 	content += fmt.Sprintf("<ID>%s</ID>", internal.UUID())
 	content += "<Status>Enabled</Status>"
-	content += fmt.Sprintf("<Filter><Prefix>%s</Prefix></Filter>", options["prefix"])
-	content += fmt.Sprintf("<Expiration><Days>%s</Days></Expiration>", options["expiration"])
+	content += fmt.Sprintf("<Filter><Prefix>%s</Prefix></Filter>", opts.Prefix)
+	content += fmt.Sprintf("<Expiration><Days>%d</Days></Expiration>", opts.Expiration)
 	content += "</Rule></LifecycleConfiguration>"
 	subObject := "?lifecycle"
-	addr := fmt.Sprintf("http://%s.%s/%s", bucket, c.host, subObject)
+	addr := c.endpoint.URL(bucket, "") + subObject
 	method := "PUT"
 	LF := "\n"
 	date := time.Unix(time.Now().Unix()-8*3600, 0).Format(c.dateTimeGMT)
@@ -362,36 +464,46 @@ func (c *Client) SetLifecycle(bucket string, options map[string]string) (http.He
 		"Content-Md5": contentMd5 + LF,
 		"Date":        date,
 	}
-	headers["Authorization"] = c.sign(method, headers, bucket, subObject)
+	auth, err := c.sign(ctx, method, headers, bucket, subObject)
+	if err != nil {
+		return nil, fmt.Errorf(" SetLifecycle Bucket: %s Error: %v", bucket, err)
+	}
+	headers["Authorization"] = auth
 	headers["Content-Length"] = contentLength
 	headers["Content-Md5"] = strings.TrimSuffix(headers["Content-Md5"], LF)
 	body := &bytes.Buffer{}
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(content), body, nil)
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(content), body)
 	if err != nil {
 		return nil, fmt.Errorf(" SetLifecycle Bucket: %s Error: %v", bucket, err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" SetLifecycle Bucket: %s StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", bucket, status, reqID, errorMsg.Code, errorMsg.Message)
+		return nil, internal.NewResponseError("SetLifecycle", bucket, "", header, errBody)
 	}
 	return header, nil
 }
 
 // DeleteLifecycle 删除bucket lifecycle
 func (c *Client) DeleteLifecycle(bucket string) (http.Header, error) {
+	return c.DeleteLifecycleWithContext(context.Background(), bucket)
+}
+
+// DeleteLifecycleWithContext 删除bucket lifecycle,支持通过ctx取消和设置超时
+func (c *Client) DeleteLifecycleWithContext(ctx context.Context, bucket string) (http.Header, error) {
 	subObject := "?lifecycle"
-	addr := fmt.Sprintf("http://%s.%s/%s", bucket, c.host, subObject)
+	addr := c.endpoint.URL(bucket, "") + subObject
 	method := "DELETE"
 	date := time.Unix(time.Now().Unix()-8*3600, 0).Format(c.dateTimeGMT)
 	headers := map[string]string{
 		"Date": date,
 	}
 	LF := "\n"
-	headers["Authorization"] = c.sign(method+LF+LF, headers, bucket+"/"+subObject, "")
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(""), nil, nil)
+	auth, err := c.sign(ctx, method+LF+LF, headers, bucket+"/"+subObject, "")
+	if err != nil {
+		return nil, fmt.Errorf(" DeleteLifecycle Bucket: %s Error: %v", bucket, err)
+	}
+	headers["Authorization"] = auth
+	header, _, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), nil)
 	if err != nil {
 		return nil, fmt.Errorf(" DeleteLifecycle Bucket: %s Error: %v", bucket, err)
 	}
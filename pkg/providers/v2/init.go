@@ -1,35 +1,53 @@
 package v2
 
+import (
+	"github.com/shideqin/go-s3-sdk/pkg/internal"
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
 // Client 客户端结构
 type Client struct {
-	host            string
-	accessKeyID     string
-	accessKeySecret string
+	endpoint           *internal.Endpoint
+	host               string
+	credentials        s3.CredentialsProvider
+	checksum           s3.ChecksumAlgorithm
+	checksumValidation bool
 
 	dateTimeGMT string
 	dateTimeCST string
 
-	partMaxSize  int
-	partMinSize  int
-	maxRetryNum  int
-	threadMaxNum int
-	threadMinNum int
+	limits internal.Limits
+
+	http *internal.Client
 }
 
-// New 实例化
-func New(host, accessKeyID, accessKeySecret string) *Client {
+// New 实例化,host支持 host[:port] 或 scheme://host[:port][/path] 形式,未指定协议时使用https
+func New(host, accessKeyID, accessKeySecret string, opts ...s3.Option) *Client {
+	cfg := s3.NewConfig(opts...)
+	endpoint := internal.ParseEndpoint(host)
+	endpoint.PathStyle = cfg.PathStyle
+	credentials := cfg.Credentials
+	if credentials == nil {
+		credentials = s3.StaticCredentials(accessKeyID, accessKeySecret, "")
+	}
 	return &Client{
-		host:            host,
-		accessKeyID:     accessKeyID,
-		accessKeySecret: accessKeySecret,
+		endpoint:    endpoint,
+		host:        endpoint.Host,
+		credentials: credentials,
+		checksum:    cfg.Checksum,
+
+		checksumValidation: cfg.ChecksumValidation,
 
 		dateTimeGMT: "Mon, 02 Jan 2006 15:04:05 GMT",
 		dateTimeCST: "2006-01-02 15:04:05.00000 +0800 CST",
 
-		partMaxSize:  10 * 1024 * 1024,
-		partMinSize:  1 * 1024 * 1024,
-		maxRetryNum:  10,
-		threadMaxNum: 10,
-		threadMinNum: 1,
+		limits: internal.NewLimits(cfg),
+
+		http: internal.NewClient(cfg),
 	}
 }
+
+// Credentials 客户端使用的凭证提供者,可用于pkg/signer对其他请求签名
+func (c *Client) Credentials() s3.CredentialsProvider {
+	return c.credentials
+}
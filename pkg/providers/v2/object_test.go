@@ -0,0 +1,215 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
+func TestWithOptionsInvalid(t *testing.T) {
+	f, srv := newFakeS3(t)
+	c := newTestClient(srv)
+	ctx := context.Background()
+	localFile := filepath.Join(t.TempDir(), "local.bin")
+	if err := os.WriteFile(localFile, []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"ListObject MaxKeys", func() error {
+			_, err := c.ListObjectWithOptions(ctx, "bucket", &s3.ListOptions{MaxKeys: -1})
+			return err
+		}},
+		{"ListPart MaxUploads", func() error {
+			_, err := c.ListPartWithOptions(ctx, "bucket", &s3.ListPartOptions{MaxUploads: -1})
+			return err
+		}},
+		{"SetLifecycle Expiration", func() error {
+			_, err := c.SetLifecycleWithOptions(ctx, "bucket", &s3.LifecycleOptions{Prefix: "tmp/"})
+			return err
+		}},
+		{"Get PartSize", func() error {
+			_, err := c.GetWithOptions(ctx, "bucket", "key", localFile+".get", &s3.GetOptions{PartSize: 1})
+			return err
+		}},
+		{"Get ThreadNum", func() error {
+			_, err := c.GetWithOptions(ctx, "bucket", "key", localFile+".get", &s3.GetOptions{ThreadNum: -1})
+			return err
+		}},
+		{"UploadLargeFile PartSize", func() error {
+			_, err := c.UploadLargeFileWithOptions(ctx, localFile, "bucket", "key", &s3.MultipartOptions{PartSize: 2 << 20})
+			return err
+		}},
+		{"UploadFromDir ThreadNum", func() error {
+			_, err := c.UploadFromDirWithOptions(ctx, filepath.Dir(localFile), "bucket", "prefix", &s3.BulkOptions{ThreadNum: 1 << 20})
+			return err
+		}},
+		{"DeleteAllObject ThreadNum", func() error {
+			_, err := c.DeleteAllObjectWithOptions(ctx, "bucket", "prefix", &s3.BulkOptions{ThreadNum: -1})
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		if err := tt.call(); !errors.Is(err, s3.ErrInvalidOption) {
+			t.Errorf("%s err = %v, want ErrInvalidOption", tt.name, err)
+		}
+	}
+	// 参数校验在发送请求之前
+	if len(f.requests) != 0 {
+		t.Errorf("requests = %d, want 0", len(f.requests))
+	}
+}
+
+func TestListObjectWithOptions(t *testing.T) {
+	f, srv := newFakeS3(t)
+	c := newTestClient(srv)
+	ctx := context.Background()
+	f.objects["bucket/a.txt"] = []byte("a")
+	f.objects["bucket/dir/b.txt"] = []byte("bb")
+	f.objects["bucket/dir/c d.txt"] = []byte("ccc")
+
+	// 没有查询参数时URL不以?结尾
+	list, err := c.ListObjectWithOptions(ctx, "bucket", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri := f.lastRequest().RequestURI; uri != "/bucket/" {
+		t.Errorf("RequestURI = %q, want /bucket/", uri)
+	}
+	if len(list.Contents) != 3 {
+		t.Errorf("Contents = %+v, want 3 objects", list.Contents)
+	}
+
+	list, err = c.ListObjectWithOptions(ctx, "bucket", &s3.ListOptions{Delimiter: "/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Contents) != 1 || list.Contents[0].Key != "a.txt" || len(list.CommonPrefixes) != 1 || list.CommonPrefixes[0].Prefix != "dir/" {
+		t.Errorf("list with delimiter = %+v", list)
+	}
+
+	list, err = c.ListObjectWithOptions(ctx, "bucket", &s3.ListOptions{Prefix: "dir/", Marker: "dir/b.txt", MaxKeys: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query := f.lastRequest().URL.Query(); query.Get("max-keys") != "1" || query.Get("marker") != "dir/b.txt" || query.Get("prefix") != "dir/" {
+		t.Errorf("query = %v", query)
+	}
+	if len(list.Contents) != 1 || list.Contents[0].Key != "dir/c d.txt" || list.Contents[0].Size != 3 {
+		t.Errorf("list after marker = %+v", list.Contents)
+	}
+}
+
+func TestPutWithOptionsResult(t *testing.T) {
+	f, srv := newFakeS3(t)
+	c := newTestClient(srv)
+	content := "hello world"
+	result, err := c.PutWithOptions(context.Background(), strings.NewReader(content), len(content), "bucket", "dir/hello.txt", &s3.PutOptions{ACL: "public-read", Disposition: "hello.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Bucket != "bucket" || result.Key != "dir/hello.txt" || result.Size != int64(len(content)) || result.StatusCode != http.StatusOK {
+		t.Errorf("PutResult = %+v", result)
+	}
+	if result.ETag != etag([]byte(content)) {
+		t.Errorf("ETag = %s, want %s", result.ETag, etag([]byte(content)))
+	}
+	if result.Location != srv.URL+"/bucket/dir/hello.txt" {
+		t.Errorf("Location = %s", result.Location)
+	}
+	r := f.lastRequest()
+	if r.Header.Get("X-Amz-Acl") != "public-read" || r.Header.Get("Content-Disposition") != `attachment; filename="hello.txt"` {
+		t.Errorf("headers = %v", r.Header)
+	}
+	if m := result.Map(); m["StatusCode"] != "200" || m["Size"] != len(content) {
+		t.Errorf("Map = %v", m)
+	}
+}
+
+func TestGetWithOptionsResult(t *testing.T) {
+	f, srv := newFakeS3(t)
+	c := newTestClient(srv)
+	content := []byte("0123456789")
+	f.objects["bucket/dir/data.bin"] = content
+	localDir := t.TempDir() + "/"
+	// localFile为目录时使用object的文件名
+	info, err := c.GetWithOptions(context.Background(), "bucket", "dir/data.bin", localDir, &s3.GetOptions{PartSize: 4, ThreadNum: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Bucket != "bucket" || info.Key != "dir/data.bin" || info.Size != int64(len(content)) || info.ETag != etag(content) {
+		t.Errorf("ObjectInfo = %+v", info)
+	}
+	if !info.LastModified.Equal(testModTime) {
+		t.Errorf("LastModified = %s, want %s", info.LastModified, testModTime)
+	}
+	if info.LocalFile != localDir+"data.bin" {
+		t.Errorf("LocalFile = %s", info.LocalFile)
+	}
+	if data, err := os.ReadFile(info.LocalFile); err != nil || string(data) != string(content) {
+		t.Errorf("local file = %q, %v", data, err)
+	}
+	if n := f.requestsMatching(http.MethodGet, ""); n != 3 {
+		t.Errorf("GET requests = %d, want 3 parts", n)
+	}
+}
+
+func TestBulkResult(t *testing.T) {
+	f, srv := newFakeS3(t)
+	c := newTestClient(srv)
+	ctx := context.Background()
+	localDir := t.TempDir()
+	files := map[string]string{"a.txt": "a", "b.txt": "bb", "c.log": "ccc", "locked.txt": "dddd"}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(localDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 远端大小相同且不早于本地文件的对象被跳过
+	f.objects["bucket/up/a.txt"] = []byte("a")
+	old := testModTime.Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(localDir, "a.txt"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	result, err := c.UploadFromDirWithOptions(ctx, localDir, "bucket", "up", &s3.BulkOptions{Suffix: []string{".txt"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 3 || result.Finish != 2 || result.Skip != 1 || result.Size != 6 || len(result.Failed) != 0 {
+		t.Errorf("UploadFromDir result = %+v", result)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0] != "a.txt" {
+		t.Errorf("Skipped = %v", result.Skipped)
+	}
+	if _, ok := f.object("bucket/up/c.log"); ok {
+		t.Error("file not matching Suffix uploaded")
+	}
+
+	// 删除失败的对象记录在Failed中,不计入Finish和Size
+	result, err = c.DeleteAllObjectWithOptions(ctx, "bucket", "up/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 3 || result.Finish != 2 || result.Size != 3 {
+		t.Errorf("DeleteAllObject result = %+v", result)
+	}
+	var respErr *s3.ResponseError
+	if len(result.Failed) != 1 || result.Failed[0].Key != "up/locked.txt" || !errors.As(result.Failed[0].Err, &respErr) || respErr.Code != "AccessDenied" {
+		t.Errorf("Failed = %+v", result.Failed)
+	}
+	if _, ok := f.object("bucket/up/locked.txt"); !ok {
+		t.Error("locked object deleted")
+	}
+	if m := result.Map(); m["Total"] != 3 || m["Finish"] != 2 {
+		t.Errorf("Map = %v", m)
+	}
+}
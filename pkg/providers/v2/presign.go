@@ -0,0 +1,124 @@
+package v2
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shideqin/go-s3-sdk/pkg/internal"
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+	"github.com/shideqin/go-s3-sdk/pkg/signer"
+)
+
+// Presign 生成预签名URL,method为空时使用GET,expires为0时有效期15分钟
+func (c *Client) Presign(method, bucket, object string, expires time.Duration) (string, error) {
+	return c.PresignWithOptions(context.Background(), bucket, object, &s3.PresignOptions{Method: method, Expires: expires})
+}
+
+// PresignWithOptions 生成预签名URL,支持子资源、response-*等查询参数及参与签名的请求头,参数非法时返回s3.ErrInvalidOption
+// v2只有Content-Md5、Content-Type及x-amz-头参与签名
+func (c *Client) PresignWithOptions(ctx context.Context, bucket, object string, opts *s3.PresignOptions) (string, error) {
+	if opts == nil {
+		opts = &s3.PresignOptions{}
+	}
+	expires := opts.Expires
+	if expires == 0 {
+		expires = 15 * time.Minute
+	}
+	if expires < time.Second {
+		return "", fmt.Errorf(" Presign Object: %s Error: %w: Expires %s must be at least 1s", object, s3.ErrInvalidOption, expires)
+	}
+	return c.presign(ctx, bucket, object, opts, time.Now().Add(expires).Unix())
+}
+
+func (c *Client) presign(ctx context.Context, bucket, object string, opts *s3.PresignOptions, expires int64) (string, error) {
+	method := strings.ToUpper(opts.Method)
+	if method == "" {
+		method = "GET"
+	}
+	nObject := url.QueryEscape(object)
+	//Expires代替Date参与签名,缺少的Content-Md5、Content-Type以空行代替
+	headers := map[string]string{
+		"Content-Md5":  "",
+		"Content-Type": "",
+		"Date":         strconv.FormatInt(expires, 10),
+	}
+	for k, v := range opts.Headers {
+		switch key := strings.ToLower(k); {
+		case key == "content-md5":
+			headers["Content-Md5"] = v
+		case key == "content-type":
+			headers["Content-Type"] = v
+		case strings.HasPrefix(key, "x-amz-"):
+			headers[key] = strings.TrimSpace(v)
+		default:
+			return "", fmt.Errorf(" Presign Object: %s Error: %w: header %s can not be signed", object, s3.ErrInvalidOption, k)
+		}
+	}
+	query := url.Values{}
+	var resources []string
+	for k, v := range opts.Query {
+		query.Set(k, v)
+		if signer.V2SubResources[k] {
+			resources = append(resources, k)
+		}
+	}
+	//子资源按名称排序,值不编码
+	sort.Strings(resources)
+	resource := nObject
+	for i, k := range resources {
+		sep := "&"
+		if i == 0 {
+			sep = "?"
+		}
+		resource += sep + k
+		if v := opts.Query[k]; v != "" {
+			resource += "=" + v
+		}
+	}
+	auth, err := c.sign(ctx, method, headers, bucket, resource)
+	if err != nil {
+		return "", fmt.Errorf(" Presign Object: %s Error: %v", object, err)
+	}
+	//Authorization格式: AWS AccessKeyId:Signature
+	accessKeyID, signature, _ := strings.Cut(strings.TrimPrefix(auth, "AWS "), ":")
+	query.Set("AWSAccessKeyId", accessKeyID)
+	query.Set("Expires", headers["Date"])
+	query.Set("Signature", signature)
+	if token := headers["x-amz-security-token"]; token != "" {
+		query.Set("x-amz-security-token", token)
+	}
+	return c.endpoint.URL(bucket, nObject) + "?" + strings.ReplaceAll(query.Encode(), "+", "%20"), nil
+}
+
+// PresignPost 生成浏览器表单上传的地址及字段
+func (c *Client) PresignPost(policy *s3.PostPolicy) (*s3.PostForm, error) {
+	return c.PresignPostWithContext(context.Background(), policy)
+}
+
+// PresignPostWithContext 生成浏览器表单上传的地址及字段,参数非法时返回s3.ErrInvalidOption
+func (c *Client) PresignPostWithContext(ctx context.Context, policy *s3.PostPolicy) (*s3.PostForm, error) {
+	if policy == nil {
+		policy = &s3.PostPolicy{}
+	}
+	creds, err := c.credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(" PresignPost Bucket: %s Error: %v", policy.Bucket, err)
+	}
+	signFields := map[string]string{}
+	if creds.SessionToken != "" {
+		signFields["x-amz-security-token"] = creds.SessionToken
+	}
+	encoded, fields, err := internal.PostPolicy(policy, time.Now(), signFields)
+	if err != nil {
+		return nil, fmt.Errorf(" PresignPost Bucket: %s Error: %w", policy.Bucket, err)
+	}
+	//AWSAccessKeyId不作为policy的条件
+	fields["AWSAccessKeyId"] = creds.AccessKeyID
+	fields["Signature"] = internal.Base64Encode([]byte(hmacEncode(encoded, creds.SecretAccessKey)))
+	return &s3.PostForm{URL: c.endpoint.URL(policy.Bucket, ""), Fields: fields}, nil
+}
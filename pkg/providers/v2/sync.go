@@ -1,6 +1,7 @@
 package v2
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -8,7 +9,6 @@ import (
 	"strconv"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/shideqin/go-s3-sdk/pkg/internal"
@@ -17,27 +17,55 @@ import (
 
 // SyncLargeFile 分块同步文件
 func (c *Client) SyncLargeFile(toClient s3.Client, bucket, object, source string, options map[string]string, percentChan chan int) (map[string]interface{}, error) {
+	contextClient, ok := toClient.(s3.ContextClient)
+	if !ok {
+		return nil, fmt.Errorf(" SyncLargeFile toClient: %T does not implement s3.ContextClient", toClient)
+	}
+	return c.SyncLargeFileWithContext(context.Background(), contextClient, bucket, object, source, options, percentChan)
+}
+
+// SyncLargeFileWithContext 分块同步文件,支持通过ctx取消和设置超时
+func (c *Client) SyncLargeFileWithContext(ctx context.Context, toClient s3.ContextClient, bucket, object, source string, options map[string]string, percentChan chan int) (map[string]interface{}, error) {
+	optionsClient, ok := toClient.(s3.OptionsClient)
+	if !ok {
+		return nil, fmt.Errorf(" SyncLargeFile toClient: %T does not implement s3.OptionsClient", toClient)
+	}
+	result, err := c.syncLargeFile(ctx, optionsClient, bucket, object, source, c.limits.MultipartOptions(options), percentChan)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// SyncLargeFileWithOptions 分块同步文件,参数非法时返回s3.ErrInvalidOption
+func (c *Client) SyncLargeFileWithOptions(ctx context.Context, toClient s3.OptionsClient, bucket, object, source string, opts *s3.MultipartOptions) (*s3.PutResult, error) {
+	return c.syncLargeFile(ctx, toClient, bucket, object, source, opts, nil)
+}
+
+func (c *Client) syncLargeFile(ctx context.Context, toClient s3.OptionsClient, bucket, object, source string, opts *s3.MultipartOptions, percentChan chan int) (result *s3.PutResult, err error) {
+	if opts == nil {
+		opts = &s3.MultipartOptions{}
+	}
+	start := time.Now()
+	progress := internal.NewProgress(opts.Progress)
+	defer func() {
+		progress.Close(err)
+	}()
+	partSize, err := c.limits.PartSize(opts.PartSize, c.limits.PartMaxSize)
+	if err != nil {
+		return nil, fmt.Errorf(" SyncLargeFile Error: %w", err)
+	}
+	threadNum, err := c.limits.ThreadNum(opts.ThreadNum)
+	if err != nil {
+		return nil, fmt.Errorf(" SyncLargeFile Error: %w", err)
+	}
 	tmpSourceInfo := strings.Split(source, "/")
 	sourceBucket := tmpSourceInfo[1]
 	sourceObject := strings.Join(tmpSourceInfo[2:], "/")
-	sourceHead, headErr := c.Head(sourceBucket, sourceObject)
+	sourceHead, headErr := c.HeadWithContext(ctx, sourceBucket, sourceObject)
 	if headErr != nil {
 		return nil, headErr
 	}
-	var partSize = c.partMaxSize
-	if options["part_size"] != "" {
-		n, _ := strconv.Atoi(options["part_size"])
-		if n <= c.partMaxSize && n >= c.partMinSize {
-			partSize = n
-		}
-	}
-	var threadNum = c.threadMaxNum
-	if options["thread_num"] != "" {
-		n, _ := strconv.Atoi(options["thread_num"])
-		if n <= c.threadMaxNum && n >= c.threadMinNum {
-			threadNum = n
-		}
-	}
 
 	if object == "" {
 		object = path.Base(sourceObject)
@@ -50,16 +78,18 @@ func (c *Client) SyncLargeFile(toClient s3.Client, bucket, object, source string
 		return nil, fmt.Errorf(" SyncLargeFile Object: %s Content-Length cant not zero", object)
 	}
 	var total = (objectSize + partSize - 1) / partSize
+	progress.Start(int64(objectSize), 0)
 	if total < threadNum {
 		threadNum = total
 	}
 
 	//初化化上传
-	initUpload, initErr := toClient.InitUpload(bucket, object, map[string]string{"disposition": options["disposition"], "acl": options["acl"]})
+	initUpload, initErr := toClient.InitUploadWithOptions(ctx, bucket, object, &s3.PutOptions{ACL: opts.ACL, Disposition: opts.Disposition})
 	if initErr != nil {
 		return nil, initErr
 	}
 	var syncPartList = make([]string, total)
+	var checksumList = make([]string, total)
 	var queueMaxSize = make(chan bool, threadNum)
 	defer close(queueMaxSize)
 	var partErr error
@@ -67,6 +97,10 @@ func (c *Client) SyncLargeFile(toClient s3.Client, bucket, object, source string
 	//sync分片
 	var wg sync.WaitGroup
 	for partNum := 0; partNum < total; partNum++ {
+		if ctx.Err() != nil {
+			partErr = ctx.Err()
+			break
+		}
 		if partExit {
 			break
 		}
@@ -96,21 +130,14 @@ func (c *Client) SyncLargeFile(toClient s3.Client, bucket, object, source string
 				tFile.Close()
 				os.Remove(tFile.Name())
 			}()
-			for i := 0; i < c.maxRetryNum; i++ {
-				_, sErr := tFile.Seek(0, io.SeekStart)
-				if sErr != nil {
-					partErr = sErr
-					continue
-				}
-				_, cErr := c.Cat(sourceBucket, sourceObject, partRange, tFile)
-				if cErr != nil {
-					partErr = cErr
-					continue
-				}
-				partErr = nil
-				break
+			_, sErr := tFile.Seek(0, io.SeekStart)
+			if sErr != nil {
+				partErr = sErr
+				return
 			}
-			if partErr != nil {
+			_, cErr := c.CatWithContext(ctx, sourceBucket, sourceObject, partRange, internal.ProgressWriter(tFile, progress))
+			if cErr != nil {
+				partErr = cErr
 				return
 			}
 			stat, err := tFile.Stat()
@@ -118,27 +145,19 @@ func (c *Client) SyncLargeFile(toClient s3.Client, bucket, object, source string
 				partErr = err
 				return
 			}
-			for i := 0; i < c.maxRetryNum; i++ {
-				tFile, partErr = os.Open(tFile.Name())
-				if partErr != nil {
-					continue
-				}
-				uploadPart, uErr := toClient.UploadPart(tFile, int(stat.Size()), bucket, object, partNum+1, initUpload.UploadID)
-				if uErr != nil {
-					partErr = uErr
-					tFile.Close()
-					continue
-				}
-				partErr = nil
-				syncPartList[partNum] = uploadPart.Get("Etag")
-				break
-			}
+			tFile, partErr = os.Open(tFile.Name())
 			if partErr != nil {
 				return
 			}
-			if percentChan != nil {
-				percentChan <- total
+			uploadPart, uErr := toClient.UploadPartWithContext(ctx, tFile, int(stat.Size()), bucket, object, partNum+1, initUpload.UploadID)
+			if uErr != nil {
+				partErr = uErr
+				tFile.Close()
+				return
 			}
+			syncPartList[partNum] = uploadPart.Get("Etag")
+			checksumList[partNum] = internal.ChecksumElement(internal.ResponseChecksum(uploadPart))
+			internal.SendPercent(percentChan, total)
 		}(partNum)
 	}
 	wg.Wait()
@@ -148,49 +167,88 @@ func (c *Client) SyncLargeFile(toClient s3.Client, bucket, object, source string
 	//sync完成
 	completeSyncInfo := "<CompleteMultipartUpload>"
 	for partNum, Etag := range syncPartList {
-		completeSyncInfo += fmt.Sprintf("<Part><PartNumber>%d</PartNumber><ETag>%s</ETag></Part>", partNum+1, Etag)
+		completeSyncInfo += fmt.Sprintf("<Part><PartNumber>%d</PartNumber><ETag>%s</ETag>%s</Part>", partNum+1, Etag, checksumList[partNum])
 	}
 	completeSyncInfo += "</CompleteMultipartUpload>"
-	return toClient.CompleteUpload([]byte(completeSyncInfo), bucket, object, initUpload.UploadID, objectSize)
+	result, err = toClient.CompleteUploadWithOptions(ctx, []byte(completeSyncInfo), bucket, object, initUpload.UploadID, &s3.CompleteOptions{ObjectSize: int64(objectSize)})
+	if err != nil {
+		return nil, err
+	}
+	result.Duration = time.Since(start)
+	return result, nil
 }
 
 // SyncAllObject 同步目录
 func (c *Client) SyncAllObject(toClient s3.Client, bucket, prefix, source string, options map[string]string, percentChan chan int) (map[string]int, error) {
+	contextClient, ok := toClient.(s3.ContextClient)
+	if !ok {
+		return nil, fmt.Errorf(" SyncAllObject toClient: %T does not implement s3.ContextClient", toClient)
+	}
+	return c.SyncAllObjectWithContext(context.Background(), contextClient, bucket, prefix, source, options, percentChan)
+}
+
+// SyncAllObjectWithContext 同步目录,支持通过ctx取消和设置超时
+func (c *Client) SyncAllObjectWithContext(ctx context.Context, toClient s3.ContextClient, bucket, prefix, source string, options map[string]string, percentChan chan int) (map[string]int, error) {
+	optionsClient, ok := toClient.(s3.OptionsClient)
+	if !ok {
+		return nil, fmt.Errorf(" SyncAllObject toClient: %T does not implement s3.OptionsClient", toClient)
+	}
+	result, err := c.syncAllObject(ctx, optionsClient, bucket, prefix, source, c.limits.BulkOptions(options), percentChan)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// SyncAllObjectWithOptions 同步目录,参数非法时返回s3.ErrInvalidOption
+func (c *Client) SyncAllObjectWithOptions(ctx context.Context, toClient s3.OptionsClient, bucket, prefix, source string, opts *s3.BulkOptions) (*s3.BulkResult, error) {
+	return c.syncAllObject(ctx, toClient, bucket, prefix, source, opts, nil)
+}
+
+func (c *Client) syncAllObject(ctx context.Context, toClient s3.OptionsClient, bucket, prefix, source string, opts *s3.BulkOptions, percentChan chan int) (result *s3.BulkResult, err error) {
+	if opts == nil {
+		opts = &s3.BulkOptions{}
+	}
+	progress := internal.NewProgress(opts.Progress)
+	defer func() {
+		progress.Close(err)
+	}()
+	threadNum, err := c.limits.ThreadNum(opts.ThreadNum)
+	if err != nil {
+		return nil, fmt.Errorf(" SyncAllObject Error: %w", err)
+	}
 	if prefix != "" {
 		prefix = strings.TrimSuffix(prefix, "/") + "/"
 	}
-	maxKeys := "1000"
-	if options["max-keys"] != "" {
-		maxKeys = options["max-keys"]
+	maxKeys := opts.MaxKeys
+	if maxKeys == 0 {
+		maxKeys = 1000
 	}
 	marker := ""
 	tmpSourceInfo := strings.Split(source, "/")
 	sourceBucket := tmpSourceInfo[1]
 	sourcePrefix := strings.Join(tmpSourceInfo[2:], "/")
 	total := 0
-	var threadNum = c.threadMaxNum
-	if options["thread_num"] != "" {
-		n, _ := strconv.Atoi(options["thread_num"])
-		if n <= c.threadMaxNum && n >= c.threadMinNum {
-			threadNum = n
-		}
-	}
 	var queueMaxSize = make(chan bool, threadNum)
 	defer close(queueMaxSize)
-	var tmpSize int64
-	var tmpSkip int64
-	var tmpFinish int64
+	counter := internal.NewBulkCounter()
+	progress.Start(0, 0)
 	var fileErr error
 	var fileExit bool
 	var wg sync.WaitGroup
 LIST:
-	sourceList, listErr := c.ListObject(sourceBucket, map[string]string{"prefix": sourcePrefix, "marker": marker, "max-keys": maxKeys})
+	sourceList, listErr := c.ListObjectWithOptions(ctx, sourceBucket, &s3.ListOptions{Prefix: sourcePrefix, Marker: marker, MaxKeys: maxKeys})
 	if listErr != nil {
-		return nil, listErr
+		return counter.Result(total), listErr
 	}
 	sourceObjectNum := len(sourceList.Contents)
 	total += sourceObjectNum
+	progress.AddTotal(internal.ContentsSize(sourceList.Contents), sourceObjectNum)
 	for fileNum := 0; fileNum < sourceObjectNum; fileNum++ {
+		if ctx.Err() != nil {
+			fileErr = ctx.Err()
+			break
+		}
 		if fileExit {
 			break
 		}
@@ -204,26 +262,28 @@ LIST:
 				wg.Done()
 				<-queueMaxSize
 			}()
+			progress.FileStarted(objectInfo.Key)
 			//支持自定义前缀
 			object := prefix
-			if options["full_path"] == "true" {
+			if opts.FullPath {
 				object += strings.Replace(objectInfo.Key, sourcePrefix, "", -1)
 			} else {
 				object += path.Base(objectInfo.Key)
 			}
 			isSkipped := false
-			var sourceHead, _ = c.Head(sourceBucket, objectInfo.Key)
+			var sourceHead, _ = c.HeadWithContext(ctx, sourceBucket, objectInfo.Key)
 			var disposition = internal.GetDisposition(sourceHead.Get("Content-Disposition"))
 			var sourceHeadSize, _ = strconv.ParseInt(sourceHead.Get("Content-Length"), 10, 64)
-			if options["replace"] != "true" {
-				var objectHead, _ = c.Head(bucket, object)
+			if !opts.Replace {
+				var objectHead, _ = c.HeadWithContext(ctx, bucket, object)
 				var objectHeadSize, _ = strconv.ParseInt(objectHead.Get("Content-Length"), 10, 64)
 				if sourceHeadSize == objectHeadSize {
 					var objectTime, _ = time.Parse(c.dateTimeGMT, objectHead.Get("Last-Modified"))
 					var sourceTime, _ = time.Parse(c.dateTimeGMT, sourceHead.Get("Last-Modified"))
 					if objectTime.Unix() >= sourceTime.Unix() {
 						isSkipped = true
-						atomic.AddInt64(&tmpSkip, 1)
+						counter.Skip(objectInfo.Key)
+						progress.FileSkipped(objectInfo.Key, int64(objectInfo.Size))
 					}
 				}
 			}
@@ -231,57 +291,54 @@ LIST:
 				tFile, tErr := os.CreateTemp("", fmt.Sprintf("aws-v2-sync-all%d", fileNum))
 				if tErr != nil {
 					fileErr = tErr
+					counter.Fail(objectInfo.Key, tErr)
+					progress.FileFailed(objectInfo.Key, tErr)
 					return
 				}
 				defer func() {
 					tFile.Close()
 					os.Remove(tFile.Name())
 				}()
-				for i := 0; i < c.maxRetryNum; i++ {
-					_, sErr := tFile.Seek(0, io.SeekStart)
-					if sErr != nil {
-						fileErr = sErr
-						continue
-					}
-					_, cErr := c.Cat(sourceBucket, objectInfo.Key, "", tFile)
-					if cErr != nil {
-						fileErr = cErr
-						continue
-					}
-					fileErr = nil
-					break
+				_, sErr := tFile.Seek(0, io.SeekStart)
+				if sErr != nil {
+					fileErr = sErr
+					counter.Fail(objectInfo.Key, sErr)
+					progress.FileFailed(objectInfo.Key, sErr)
+					return
 				}
-				if fileErr != nil {
+				_, cErr := c.CatWithContext(ctx, sourceBucket, objectInfo.Key, "", internal.ProgressWriter(tFile, progress))
+				if cErr != nil {
+					fileErr = cErr
+					counter.Fail(objectInfo.Key, cErr)
+					progress.FileFailed(objectInfo.Key, cErr)
 					return
 				}
 				stat, err := tFile.Stat()
 				if err != nil {
 					fileErr = err
+					counter.Fail(objectInfo.Key, err)
+					progress.FileFailed(objectInfo.Key, err)
 					return
 				}
-				for i := 0; i < c.maxRetryNum; i++ {
-					tFile, fileErr = os.Open(tFile.Name())
-					if fileErr != nil {
-						continue
-					}
-					_, pErr := toClient.Put(tFile, int(stat.Size()), bucket, object, map[string]string{"disposition": disposition, "acl": options["acl"]})
-					if pErr != nil {
-						fileErr = pErr
-						tFile.Close()
-						continue
-					}
-					fileErr = nil
-					break
+				tFile, err = os.Open(tFile.Name())
+				if err != nil {
+					fileErr = err
+					counter.Fail(objectInfo.Key, err)
+					progress.FileFailed(objectInfo.Key, err)
+					return
 				}
-				if fileErr != nil {
+				_, pErr := toClient.PutWithOptions(ctx, tFile, int(stat.Size()), bucket, object, &s3.PutOptions{ACL: opts.ACL, Disposition: disposition})
+				if pErr != nil {
+					fileErr = pErr
+					counter.Fail(objectInfo.Key, pErr)
+					progress.FileFailed(objectInfo.Key, pErr)
+					tFile.Close()
 					return
 				}
-				atomic.AddInt64(&tmpSize, sourceHeadSize)
-				atomic.AddInt64(&tmpFinish, 1)
-			}
-			if percentChan != nil {
-				percentChan <- total
+				counter.Finish(1, sourceHeadSize)
+				progress.FileCompleted(objectInfo.Key)
 			}
+			internal.SendPercent(percentChan, total)
 		}(fileNum, sourceList.Contents[fileNum])
 	}
 	wg.Wait()
@@ -289,11 +346,5 @@ LIST:
 		marker = sourceList.Contents[sourceObjectNum-1].Key
 		goto LIST
 	}
-	if fileErr != nil {
-		return nil, fileErr
-	}
-	skip := int(atomic.LoadInt64(&tmpSkip))
-	finish := int(atomic.LoadInt64(&tmpFinish))
-	size := int(atomic.LoadInt64(&tmpSize))
-	return map[string]int{"Total": total, "Skip": skip, "Finish": finish, "Size": size}, nil
+	return counter.Result(total), fileErr
 }
@@ -2,14 +2,20 @@ package v4
 
 import (
 	"bufio"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"io"
+	"net/url"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+	"github.com/shideqin/go-s3-sdk/pkg/signer"
 )
 
 //byte pool
@@ -25,34 +31,84 @@ import (
 //	}
 //}
 
-func (c *Client) sign(method string, headers map[string]string, uri, canonQuery string) string {
+func (c *Client) sign(ctx context.Context, method string, headers map[string]string, uri string, query url.Values) (string, error) {
+	creds, err := c.credentials.Retrieve(ctx)
+	if err != nil {
+		return "", err
+	}
+	auth, _ := c.signWith(creds, method, headers, uri, query)
+	return auth, nil
+}
+
+// signWith 使用指定凭证签名,返回Authorization及签名
+func (c *Client) signWith(creds s3.Credentials, method string, headers map[string]string, uri string, query url.Values) (string, string) {
+	//STS临时凭证的token需参与签名
+	if creds.SessionToken != "" {
+		headers["x-amz-security-token"] = creds.SessionToken
+	}
 	dt, _ := time.Parse(c.iso8601FormatDateTime, headers["x-amz-date"])
 	credentialString := c.buildCredentialString(dt)
 	signHeaders := c.canonicalSignHeaders(headers)
-	signature := c.buildSignature(method, headers, uri, canonQuery, dt)
+	signature := c.buildSignature(creds.SecretAccessKey, method, headers, uri, signer.CanonicalQuery(query), headers["x-amz-content-sha256"], dt)
 	return fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
 		c.authHeaderPrefix,
-		c.accessKeyID,
+		creds.AccessKeyID,
 		credentialString,
 		signHeaders,
-		signature)
+		signature), signature
+}
+
+// signBody 按签名方式对带请求体的请求签名并设置Content-Length,返回Authorization及实际发送的请求体
+func (c *Client) signBody(ctx context.Context, method string, headers map[string]string, uri string, query url.Values, content io.Reader, bodySize int) (string, io.Reader, error) {
+	creds, err := c.credentials.Retrieve(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	mode := c.payloadSigning
+	//UNSIGNED-PAYLOAD依赖TLS保证请求体完整性
+	if mode == s3.PayloadUnsigned && c.endpoint.Scheme != "https" {
+		mode = s3.PayloadSigned
+	}
+	//不可Seek的请求体无法预先计算sha256,改为分块签名
+	rs, seekable := content.(io.ReadSeeker)
+	if mode == s3.PayloadSigned && !seekable {
+		mode = s3.PayloadStreaming
+	}
+	switch mode {
+	case s3.PayloadUnsigned:
+		headers["x-amz-content-sha256"] = c.unsignedPayload
+	case s3.PayloadStreaming:
+		headers["x-amz-content-sha256"] = streamingPayload
+		headers["content-encoding"] = "aws-chunked"
+		headers["x-amz-decoded-content-length"] = strconv.Itoa(bodySize)
+	default:
+		headers["x-amz-content-sha256"] = hex.EncodeToString(hashSHA256Reader(rs))
+	}
+	auth, signature := c.signWith(creds, method, headers, uri, query)
+	headers["Content-Length"] = strconv.Itoa(bodySize)
+	if mode == s3.PayloadStreaming {
+		dt, _ := time.Parse(c.iso8601FormatDateTime, headers["x-amz-date"])
+		content = c.newChunkedReader(content, int64(bodySize), c.deriveSigningKey(creds.SecretAccessKey, dt), dt, signature)
+		headers["Content-Length"] = strconv.FormatInt(chunkedLength(int64(bodySize)), 10)
+	}
+	return auth, content, nil
 }
 
 // 得出最终的签名结果
-func (c *Client) buildSignature(method string, headers map[string]string, uri, canonQuery string, dt time.Time) string {
-	signKey := c.deriveSigningKey(dt)
-	strToSign := c.stringToSign(method, headers, uri, canonQuery, dt)
+func (c *Client) buildSignature(secret, method string, headers map[string]string, uri, canonQuery, payloadHash string, dt time.Time) string {
+	signKey := c.deriveSigningKey(secret, dt)
+	strToSign := c.stringToSign(method, headers, uri, canonQuery, payloadHash, dt)
 	signature := hmacSHA256(signKey, []byte(strToSign))
 	return hex.EncodeToString(signature)
 }
 
 // 创建待签名字符串
-func (c *Client) stringToSign(method string, headers map[string]string, uri, canonQuery string, dt time.Time) string {
+func (c *Client) stringToSign(method string, headers map[string]string, uri, canonQuery, payloadHash string, dt time.Time) string {
 	credentialString := c.buildCredentialString(dt)
-	canonicalString := c.canonicalRequest(method, headers, uri, canonQuery)
+	canonicalString := c.canonicalRequest(method, headers, uri, canonQuery, payloadHash)
 	return strings.Join([]string{
 		c.authHeaderPrefix,
-		headers["x-amz-date"],
+		dt.Format(c.iso8601FormatDateTime),
 		credentialString,
 		hex.EncodeToString(hashSHA256([]byte(canonicalString))),
 	}, "\n")
@@ -60,18 +116,17 @@ func (c *Client) stringToSign(method string, headers map[string]string, uri, can
 
 // 构建签名凭据
 func (c *Client) buildCredentialString(dt time.Time) string {
-	hostInfo := strings.Split(c.host, ".")
 	credentialString := fmt.Sprintf("%s/%s/%s/%s",
 		dt.Format(c.iso8601FormatDate),
-		hostInfo[1],
-		hostInfo[0],
+		c.region,
+		c.service,
 		c.awsV4Request,
 	)
 	return credentialString
 }
 
 // 构建规范的请求字符串
-func (c *Client) canonicalRequest(method string, headers map[string]string, uri, canonQuery string) string {
+func (c *Client) canonicalRequest(method string, headers map[string]string, uri, canonQuery, payloadHash string) string {
 	signHeader := c.canonicalSignHeaders(headers)
 	canonHeader := c.canonicalHeaders(headers)
 	return strings.Join([]string{
@@ -80,7 +135,7 @@ func (c *Client) canonicalRequest(method string, headers map[string]string, uri,
 		canonQuery,
 		canonHeader,
 		signHeader,
-		headers["x-amz-content-sha256"],
+		payloadHash,
 	}, "\n")
 }
 
@@ -111,11 +166,10 @@ func (c *Client) canonicalSignHeaders(headers map[string]string) string {
 }
 
 // 将秘钥加入到sign中
-func (c *Client) deriveSigningKey(dt time.Time) []byte {
-	hostInfo := strings.Split(c.host, ".")
-	kDate := hmacSHA256([]byte("AWS4"+c.accessKeySecret), []byte(dt.Format(c.iso8601FormatDate)))
-	kRegion := hmacSHA256(kDate, []byte(hostInfo[1]))
-	kService := hmacSHA256(kRegion, []byte(hostInfo[0]))
+func (c *Client) deriveSigningKey(secret string, dt time.Time) []byte {
+	kDate := hmacSHA256([]byte("AWS4"+secret), []byte(dt.Format(c.iso8601FormatDate)))
+	kRegion := hmacSHA256(kDate, []byte(c.region))
+	kService := hmacSHA256(kRegion, []byte(c.service))
 	signingKey := hmacSHA256(kService, []byte(c.awsV4Request))
 	return signingKey
 }
@@ -132,8 +186,7 @@ func hashSHA256(data []byte) []byte {
 	return h.Sum(nil)
 }
 
-func hashSHA256Reader(body io.Reader) []byte {
-	rs := body.(io.ReadSeeker)
+func hashSHA256Reader(rs io.ReadSeeker) []byte {
 	start, _ := rs.Seek(0, io.SeekCurrent) // Read the whole stream
 	defer func() {
 		_, _ = rs.Seek(start, io.SeekStart) // Rewind stream at end
@@ -2,18 +2,20 @@ package v4
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"encoding/xml"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/shideqin/go-s3-sdk/pkg/internal"
 	"github.com/shideqin/go-s3-sdk/pkg/s3"
+	"github.com/shideqin/go-s3-sdk/pkg/signer"
 )
 
 // ServiceResult 获取bucket列表结果
@@ -30,26 +32,32 @@ type ListPartsResult = s3.ListPartsResult
 
 // GetService 获取bucket列表
 func (c *Client) GetService() (*ServiceResult, error) {
-	addr := fmt.Sprintf("http://%s/", c.host)
+	return c.GetServiceWithContext(context.Background())
+}
+
+// GetServiceWithContext 获取bucket列表,支持通过ctx取消和设置超时
+func (c *Client) GetServiceWithContext(ctx context.Context) (*ServiceResult, error) {
+	addr := c.endpoint.URL("", "")
 	method := "GET"
 	date := time.Now().UTC().Format(c.iso8601FormatDateTime)
 	headers := map[string]string{
-		"host":                 c.host,
+		"host":                 c.endpoint.Host,
 		"x-amz-date":           date,
 		"x-amz-content-sha256": c.emptyStringSHA256,
 	}
-	headers["Authorization"] = c.sign(method, headers, "/", "")
+	auth, err := c.sign(ctx, method, headers, c.endpoint.URI("", ""), nil)
+	if err != nil {
+		return nil, fmt.Errorf(" GetService Error: %v", err)
+	}
+	headers["Authorization"] = auth
 	body := &bytes.Buffer{}
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(""), body, nil)
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), body)
 	if err != nil {
 		return nil, fmt.Errorf(" GetService Error: %v", err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" GetService StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", status, reqID, errorMsg.Code, errorMsg.Message)
+		return nil, internal.NewResponseError("GetService", "", "", header, errBody)
 	}
 	var service = &ServiceResult{}
 	if err = xml.Unmarshal(body.Bytes(), service); err != nil {
@@ -60,41 +68,63 @@ func (c *Client) GetService() (*ServiceResult, error) {
 
 // CreateBucket 创建bucket
 func (c *Client) CreateBucket(bucket string, options map[string]string) (http.Header, error) {
-	host := fmt.Sprintf("%s.%s", bucket, c.host)
-	addr := fmt.Sprintf("http://%s/", host)
+	return c.CreateBucketWithContext(context.Background(), bucket, options)
+}
+
+// CreateBucketWithContext 创建bucket,支持通过ctx取消和设置超时
+func (c *Client) CreateBucketWithContext(ctx context.Context, bucket string, options map[string]string) (http.Header, error) {
+	return c.CreateBucketWithOptions(ctx, bucket, internal.BucketOptions(options))
+}
+
+// CreateBucketWithOptions 创建bucket,参数非法时返回s3.ErrInvalidOption
+func (c *Client) CreateBucketWithOptions(ctx context.Context, bucket string, opts *s3.BucketOptions) (http.Header, error) {
+	if opts == nil {
+		opts = &s3.BucketOptions{}
+	}
+	host := c.endpoint.BucketHost(bucket)
+	addr := c.endpoint.URL(bucket, "")
 	method := "PUT"
 	date := time.Now().UTC().Format(c.iso8601FormatDateTime)
-	region := strings.Split(c.host, ".")[1]
-	content := `<CreateBucketConfiguration><LocationConstraint>` + region + `</LocationConstraint></CreateBucketConfiguration>`
+	//us-east-1不能指定LocationConstraint
+	content := ""
+	if c.region != internal.DefaultRegion {
+		content = `<CreateBucketConfiguration><LocationConstraint>` + c.region + `</LocationConstraint></CreateBucketConfiguration>`
+	}
 	contentSha256 := hex.EncodeToString(hashSHA256([]byte(content)))
 	headers := map[string]string{
 		"host":                 host,
 		"x-amz-date":           date,
 		"x-amz-content-sha256": contentSha256,
 	}
-	if options["acl"] != "" {
-		headers["x-amz-acl"] = options["acl"]
+	if opts.ACL != "" {
+		headers["x-amz-acl"] = opts.ACL
 	}
-	headers["Authorization"] = c.sign(method, headers, "/", "")
+	auth, err := c.sign(ctx, method, headers, c.endpoint.URI(bucket, ""), nil)
+	if err != nil {
+		return nil, fmt.Errorf(" CreateBucket Bucket: %s Error: %v", bucket, err)
+	}
+	headers["Authorization"] = auth
 	body := &bytes.Buffer{}
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(content), body, nil)
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(content), body)
 	if err != nil {
 		return nil, fmt.Errorf(" CreateBucket Bucket: %s Error: %v", bucket, err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" CreateBucket Bucket: %s StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", bucket, status, reqID, errorMsg.Code, errorMsg.Message)
+		return nil, internal.NewResponseError("CreateBucket", bucket, "", header, errBody)
 	}
 	return header, nil
 }
 
 // DeleteBucket 删除bucket
 func (c *Client) DeleteBucket(bucket string) (http.Header, error) {
-	host := fmt.Sprintf("%s.%s", bucket, c.host)
-	addr := fmt.Sprintf("http://%s/", host)
+	return c.DeleteBucketWithContext(context.Background(), bucket)
+}
+
+// DeleteBucketWithContext 删除bucket,支持通过ctx取消和设置超时
+func (c *Client) DeleteBucketWithContext(ctx context.Context, bucket string) (http.Header, error) {
+	host := c.endpoint.BucketHost(bucket)
+	addr := c.endpoint.URL(bucket, "")
 	method := "DELETE"
 	date := time.Now().UTC().Format(c.iso8601FormatDateTime)
 	headers := map[string]string{
@@ -102,40 +132,56 @@ func (c *Client) DeleteBucket(bucket string) (http.Header, error) {
 		"x-amz-date":           date,
 		"x-amz-content-sha256": c.emptyStringSHA256,
 	}
-	headers["Authorization"] = c.sign(method, headers, "/", "")
+	auth, err := c.sign(ctx, method, headers, c.endpoint.URI(bucket, ""), nil)
+	if err != nil {
+		return nil, fmt.Errorf(" DeleteBucket Bucket: %s Error: %v", bucket, err)
+	}
+	headers["Authorization"] = auth
 	body := &bytes.Buffer{}
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(""), body, nil)
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), body)
 	if err != nil {
 		return nil, fmt.Errorf(" DeleteBucket Bucket: %s Error: %v", bucket, err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
-	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" DeleteBucket Bucket: %s StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", bucket, status, reqID, errorMsg.Code, errorMsg.Message)
+	if status != "200" && status != "204" {
+		return nil, internal.NewResponseError("DeleteBucket", bucket, "", header, errBody)
 	}
 	return header, nil
 }
 
 // ListPart 查看分块列表
 func (c *Client) ListPart(bucket string, options map[string]string) (*ListPartsResult, error) {
-	param := ""
-	if options["delimiter"] != "" {
-		param += "&delimiter=" + options["delimiter"]
+	return c.ListPartWithContext(context.Background(), bucket, options)
+}
+
+// ListPartWithContext 查看分块列表,支持通过ctx取消和设置超时
+func (c *Client) ListPartWithContext(ctx context.Context, bucket string, options map[string]string) (*ListPartsResult, error) {
+	return c.ListPartWithOptions(ctx, bucket, internal.ListPartOptions(options))
+}
+
+// ListPartWithOptions 查看分块列表,参数非法时返回s3.ErrInvalidOption
+func (c *Client) ListPartWithOptions(ctx context.Context, bucket string, opts *s3.ListPartOptions) (*ListPartsResult, error) {
+	if opts == nil {
+		opts = &s3.ListPartOptions{}
+	}
+	query := url.Values{"uploads": {""}}
+	if opts.Delimiter != "" {
+		query.Set("delimiter", opts.Delimiter)
 	}
-	if options["key-marker"] != "" {
-		param += "&key-marker=" + options["key-marker"]
+	if opts.KeyMarker != "" {
+		query.Set("key-marker", opts.KeyMarker)
 	}
-	if options["max-keys"] != "" {
-		param += "&max-uploads=" + options["max-keys"]
+	if opts.MaxUploads < 0 {
+		return nil, fmt.Errorf(" ListPart Bucket: %s Error: %w: MaxUploads %d must not be negative", bucket, s3.ErrInvalidOption, opts.MaxUploads)
 	}
-	if options["prefix"] != "" {
-		param += "&prefix=" + options["prefix"]
+	if opts.MaxUploads > 0 {
+		query.Set("max-uploads", strconv.Itoa(opts.MaxUploads))
 	}
-	object := strings.TrimPrefix(param, "&")
-	host := fmt.Sprintf("%s.%s", bucket, c.host)
-	addr := fmt.Sprintf("http://%s/?uploads&%s", host, object)
+	if opts.Prefix != "" {
+		query.Set("prefix", opts.Prefix)
+	}
+	host := c.endpoint.BucketHost(bucket)
+	addr := c.endpoint.URL(bucket, "") + "?" + signer.CanonicalQuery(query)
 	method := "GET"
 	date := time.Now().UTC().Format(c.iso8601FormatDateTime)
 	headers := map[string]string{
@@ -143,18 +189,19 @@ func (c *Client) ListPart(bucket string, options map[string]string) (*ListPartsR
 		"x-amz-date":           date,
 		"x-amz-content-sha256": c.emptyStringSHA256,
 	}
-	headers["Authorization"] = c.sign(method, headers, "/", object+"&uploads=")
+	auth, err := c.sign(ctx, method, headers, c.endpoint.URI(bucket, ""), query)
+	if err != nil {
+		return nil, fmt.Errorf(" ListPart Bucket: %s Error: %v", bucket, err)
+	}
+	headers["Authorization"] = auth
 	body := &bytes.Buffer{}
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(""), body, nil)
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), body)
 	if err != nil {
 		return nil, fmt.Errorf(" ListPart Bucket: %s Error: %v", bucket, err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" ListPart Bucket: %s StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", bucket, status, reqID, errorMsg.Code, errorMsg.Message)
+		return nil, internal.NewResponseError("ListPart", bucket, "", header, errBody)
 	}
 	var ListParts = &ListPartsResult{}
 	if err = xml.Unmarshal(body.Bytes(), ListParts); err != nil {
@@ -165,30 +212,60 @@ func (c *Client) ListPart(bucket string, options map[string]string) (*ListPartsR
 
 // DeleteAllPart 删除所有分块
 func (c *Client) DeleteAllPart(bucket, prefix string, options map[string]string, percentChan chan int) (map[string]int, error) {
+	return c.DeleteAllPartWithContext(context.Background(), bucket, prefix, options, percentChan)
+}
+
+// DeleteAllPartWithContext 删除所有分块,支持通过ctx取消和设置超时
+func (c *Client) DeleteAllPartWithContext(ctx context.Context, bucket, prefix string, options map[string]string, percentChan chan int) (map[string]int, error) {
+	result, err := c.deleteAllPart(ctx, bucket, prefix, c.limits.BulkOptions(options), percentChan)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// DeleteAllPartWithOptions 删除所有分块,参数非法时返回s3.ErrInvalidOption
+func (c *Client) DeleteAllPartWithOptions(ctx context.Context, bucket, prefix string, opts *s3.BulkOptions) (*s3.BulkResult, error) {
+	return c.deleteAllPart(ctx, bucket, prefix, opts, nil)
+}
+
+func (c *Client) deleteAllPart(ctx context.Context, bucket, prefix string, opts *s3.BulkOptions, percentChan chan int) (result *s3.BulkResult, err error) {
+	if opts == nil {
+		opts = &s3.BulkOptions{}
+	}
+	progress := internal.NewProgress(opts.Progress)
+	defer func() {
+		progress.Close(err)
+	}()
+	threadNum, err := c.limits.ThreadNum(opts.ThreadNum)
+	if err != nil {
+		return nil, fmt.Errorf(" DeleteAllPart Error: %w", err)
+	}
 	contents := make([]map[string]string, 0)
-	maxKeys := "1000"
-	if options["max-keys"] != "" {
-		maxKeys = options["max-keys"]
+	maxKeys := opts.MaxKeys
+	if maxKeys == 0 {
+		maxKeys = 1000
 	}
 	marker := ""
 	total := 0
-	var tmpFinish int64
-	var tmpSkip int64
+	counter := internal.NewBulkCounter()
+	progress.Start(0, 0)
 	var wg sync.WaitGroup
 LIST:
-	list, err := c.ListPart(bucket, map[string]string{"prefix": prefix, "key-marker": marker, "max-keys": maxKeys})
+	list, err := c.ListPartWithOptions(ctx, bucket, &s3.ListPartOptions{Prefix: prefix, KeyMarker: marker, MaxUploads: maxKeys})
 	if err != nil {
-		return nil, err
+		return counter.Result(total), err
 	}
 	total += len(list.Upload)
+	progress.AddTotal(0, len(list.Upload))
 	if total <= 0 {
-		return map[string]int{"Total": 0, "Finish": 0}, nil
+		return counter.Result(total), nil
 	}
-	expired, _ := strconv.Atoi(options["expired"])
 	for _, v := range list.Upload {
 		lastModified, err := time.Parse("2006-01-02T15:04:05.000Z", v.Initiated)
-		if err == nil && time.Since(lastModified).Seconds() < float64(expired) {
-			atomic.AddInt64(&tmpSkip, 1)
+		if err == nil && time.Since(lastModified) < opts.Expired {
+			counter.Skip(v.Key)
+			progress.FileSkipped(v.Key, 0)
 			continue
 		}
 		contents = append(contents, map[string]string{"Bucket": bucket, "Key": v.Key, "UploadID": v.UploadID})
@@ -198,13 +275,6 @@ LIST:
 		goto LIST
 	}
 
-	var threadNum = c.threadMaxNum
-	if options["thread_num"] != "" {
-		n, _ := strconv.Atoi(options["thread_num"])
-		if n <= c.threadMaxNum && n >= c.threadMinNum {
-			threadNum = n
-		}
-	}
 	var contentSize = len(contents)
 	if contentSize < threadNum {
 		threadNum = contentSize
@@ -214,6 +284,10 @@ LIST:
 	var partErr error
 	var partExit bool
 	for partNum := 0; partNum < contentSize; partNum++ {
+		if ctx.Err() != nil {
+			partErr = ctx.Err()
+			break
+		}
 		if partExit {
 			break
 		}
@@ -227,36 +301,32 @@ LIST:
 				wg.Done()
 				<-queueMaxSize
 			}()
-			for i := 0; i < c.maxRetryNum; i++ {
-				_, partErr = c.CancelPart(body["Bucket"], body["Key"], body["UploadID"])
-				if partErr != nil {
-					continue
-				}
-				partErr = nil
-				break
-			}
-			if partErr != nil {
+			_, cErr := c.CancelPartWithContext(ctx, body["Bucket"], body["Key"], body["UploadID"])
+			if cErr != nil {
+				partErr = cErr
+				counter.Fail(body["Key"], cErr)
+				progress.FileFailed(body["Key"], cErr)
 				return
 			}
-			atomic.AddInt64(&tmpFinish, 1)
-			if percentChan != nil {
-				percentChan <- total
-			}
+			counter.Finish(1, 0)
+			progress.FileCompleted(body["Key"])
+			internal.SendPercent(percentChan, total)
 		}(partNum, contents[partNum])
 	}
 	wg.Wait()
-	if partErr != nil {
-		return nil, partErr
-	}
-	finish := int(atomic.LoadInt64(&tmpFinish))
-	skip := int(atomic.LoadInt64(&tmpSkip))
-	return map[string]int{"Total": total, "Finish": finish, "Skip": skip}, nil
+	return counter.Result(total), partErr
 }
 
 // GetACL 获取bucket acl
 func (c *Client) GetACL(bucket string) (*AclResult, error) {
-	host := fmt.Sprintf("%s.%s", bucket, c.host)
-	addr := fmt.Sprintf("http://%s/?acl", host)
+	return c.GetACLWithContext(context.Background(), bucket)
+}
+
+// GetACLWithContext 获取bucket acl,支持通过ctx取消和设置超时
+func (c *Client) GetACLWithContext(ctx context.Context, bucket string) (*AclResult, error) {
+	host := c.endpoint.BucketHost(bucket)
+	query := url.Values{"acl": {""}}
+	addr := c.endpoint.URL(bucket, "") + "?" + signer.CanonicalQuery(query)
 	method := "GET"
 	date := time.Now().UTC().Format(c.iso8601FormatDateTime)
 	headers := map[string]string{
@@ -264,18 +334,19 @@ func (c *Client) GetACL(bucket string) (*AclResult, error) {
 		"x-amz-date":           date,
 		"x-amz-content-sha256": c.emptyStringSHA256,
 	}
-	headers["Authorization"] = c.sign(method, headers, "/", "acl=")
+	auth, err := c.sign(ctx, method, headers, c.endpoint.URI(bucket, ""), query)
+	if err != nil {
+		return nil, fmt.Errorf(" GetACL Bucket: %s Error: %v", bucket, err)
+	}
+	headers["Authorization"] = auth
 	body := &bytes.Buffer{}
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(""), body, nil)
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), body)
 	if err != nil {
 		return nil, fmt.Errorf(" GetACL Bucket: %s Error: %v", bucket, err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" GetACL Bucket: %s StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", bucket, status, reqID, errorMsg.Code, errorMsg.Message)
+		return nil, internal.NewResponseError("GetACL", bucket, "", header, errBody)
 	}
 	var acl = &AclResult{}
 	if err = xml.Unmarshal(body.Bytes(), acl); err != nil {
@@ -286,8 +357,22 @@ func (c *Client) GetACL(bucket string) (*AclResult, error) {
 
 // SetACL 设置bucket acl
 func (c *Client) SetACL(bucket string, options map[string]string) (http.Header, error) {
-	host := fmt.Sprintf("%s.%s", bucket, c.host)
-	addr := fmt.Sprintf("http://%s/?acl", host)
+	return c.SetACLWithContext(context.Background(), bucket, options)
+}
+
+// SetACLWithContext 设置bucket acl,支持通过ctx取消和设置超时
+func (c *Client) SetACLWithContext(ctx context.Context, bucket string, options map[string]string) (http.Header, error) {
+	return c.SetACLWithOptions(ctx, bucket, internal.BucketOptions(options))
+}
+
+// SetACLWithOptions 设置bucket acl,参数非法时返回s3.ErrInvalidOption
+func (c *Client) SetACLWithOptions(ctx context.Context, bucket string, opts *s3.BucketOptions) (http.Header, error) {
+	if opts == nil {
+		opts = &s3.BucketOptions{}
+	}
+	host := c.endpoint.BucketHost(bucket)
+	query := url.Values{"acl": {""}}
+	addr := c.endpoint.URL(bucket, "") + "?" + signer.CanonicalQuery(query)
 	method := "PUT"
 	date := time.Now().UTC().Format(c.iso8601FormatDateTime)
 	headers := map[string]string{
@@ -295,29 +380,36 @@ func (c *Client) SetACL(bucket string, options map[string]string) (http.Header,
 		"x-amz-date":           date,
 		"x-amz-content-sha256": c.emptyStringSHA256,
 	}
-	if options["acl"] != "" {
-		headers["x-amz-acl"] = options["acl"]
+	if opts.ACL != "" {
+		headers["x-amz-acl"] = opts.ACL
+	}
+	auth, err := c.sign(ctx, method, headers, c.endpoint.URI(bucket, ""), query)
+	if err != nil {
+		return nil, fmt.Errorf(" SetACL Bucket: %s Error: %v", bucket, err)
 	}
-	headers["Authorization"] = c.sign(method, headers, "/", "acl=")
+	headers["Authorization"] = auth
 	body := &bytes.Buffer{}
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(""), body, nil)
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), body)
 	if err != nil {
 		return nil, fmt.Errorf(" SetACL Bucket: %s Error: %v", bucket, err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" SetACL Bucket: %s StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", bucket, status, reqID, errorMsg.Code, errorMsg.Message)
+		return nil, internal.NewResponseError("SetACL", bucket, "", header, errBody)
 	}
 	return header, nil
 }
 
 // GetLifecycle 获取bucket lifecycle
 func (c *Client) GetLifecycle(bucket string) (*LifecycleResult, error) {
-	host := fmt.Sprintf("%s.%s", bucket, c.host)
-	addr := fmt.Sprintf("http://%s/?lifecycle", host)
+	return c.GetLifecycleWithContext(context.Background(), bucket)
+}
+
+// GetLifecycleWithContext 获取bucket lifecycle,支持通过ctx取消和设置超时
+func (c *Client) GetLifecycleWithContext(ctx context.Context, bucket string) (*LifecycleResult, error) {
+	host := c.endpoint.BucketHost(bucket)
+	query := url.Values{"lifecycle": {""}}
+	addr := c.endpoint.URL(bucket, "") + "?" + signer.CanonicalQuery(query)
 	method := "GET"
 	date := time.Now().UTC().Format(c.iso8601FormatDateTime)
 	headers := map[string]string{
@@ -325,18 +417,19 @@ func (c *Client) GetLifecycle(bucket string) (*LifecycleResult, error) {
 		"x-amz-date":           date,
 		"x-amz-content-sha256": c.emptyStringSHA256,
 	}
-	headers["Authorization"] = c.sign(method, headers, "/", "lifecycle=")
+	auth, err := c.sign(ctx, method, headers, c.endpoint.URI(bucket, ""), query)
+	if err != nil {
+		return nil, fmt.Errorf(" GetLifecycle Bucket: %s Error: %v", bucket, err)
+	}
+	headers["Authorization"] = auth
 	body := &bytes.Buffer{}
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(""), body, nil)
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), body)
 	if err != nil {
 		return nil, fmt.Errorf(" GetLifecycle Bucket: %s Error: %v", bucket, err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" GetLifecycle Bucket: %s StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", bucket, status, reqID, errorMsg.Code, errorMsg.Message)
+		return nil, internal.NewResponseError("GetLifecycle", bucket, "", header, errBody)
 	}
 	var lifecycle = &LifecycleResult{}
 	if err = xml.Unmarshal(body.Bytes(), lifecycle); err != nil {
@@ -347,8 +440,24 @@ func (c *Client) GetLifecycle(bucket string) (*LifecycleResult, error) {
 
 // SetLifecycle 设置bucket lifecycle
 func (c *Client) SetLifecycle(bucket string, options map[string]string) (http.Header, error) {
+	return c.SetLifecycleWithContext(context.Background(), bucket, options)
+}
+
+// SetLifecycleWithContext 设置bucket lifecycle,支持通过ctx取消和设置超时
+func (c *Client) SetLifecycleWithContext(ctx context.Context, bucket string, options map[string]string) (http.Header, error) {
+	return c.SetLifecycleWithOptions(ctx, bucket, internal.LifecycleOptions(options))
+}
+
+// SetLifecycleWithOptions 设置bucket lifecycle,参数非法时返回s3.ErrInvalidOption
+func (c *Client) SetLifecycleWithOptions(ctx context.Context, bucket string, opts *s3.LifecycleOptions) (http.Header, error) {
+	if opts == nil {
+		opts = &s3.LifecycleOptions{}
+	}
+	if opts.Expiration <= 0 {
+		return nil, fmt.Errorf(" SetLifecycle Bucket: %s Error: %w: Expiration %d must be positive", bucket, s3.ErrInvalidOption, opts.Expiration)
+	}
 	content := "<LifecycleConfiguration>"
-	lifecycle, lErr := c.GetLifecycle(bucket)
+	lifecycle, lErr := c.GetLifecycleWithContext(ctx, bucket)
 	if lErr == nil {
 		for _, v := range lifecycle.Rules {
 			content += "<Rule>"
@@ -362,12 +471,13 @@ func (c *Client) SetLifecycle(bucket string, options map[string]string) (http.He
 	content += "<Rule>"
 	content += fmt.Sprintf("<ID>%s</ID>", internal.UUID())
 	content += "<Status>Enabled</Status>"
-	content += fmt.Sprintf("<Filter><Prefix>%s</Prefix></Filter>", options["prefix"])
-	content += fmt.Sprintf("<Expiration><Days>%s</Days></Expiration>", options["expiration"])
+	content += fmt.Sprintf("<Filter><Prefix>%s</Prefix></Filter>", opts.Prefix)
+	content += fmt.Sprintf("<Expiration><Days>%d</Days></Expiration>", opts.Expiration)
 	content += "</Rule></LifecycleConfiguration>"
 
-	host := fmt.Sprintf("%s.%s", bucket, c.host)
-	addr := fmt.Sprintf("http://%s/?lifecycle", host)
+	host := c.endpoint.BucketHost(bucket)
+	query := url.Values{"lifecycle": {""}}
+	addr := c.endpoint.URL(bucket, "") + "?" + signer.CanonicalQuery(query)
 	method := "PUT"
 	date := time.Now().UTC().Format(c.iso8601FormatDateTime)
 	contentMd5 := internal.Base64Encode(internal.Md5Byte([]byte(content)))
@@ -378,26 +488,33 @@ func (c *Client) SetLifecycle(bucket string, options map[string]string) (http.He
 		"x-amz-date":           date,
 		"x-amz-content-sha256": contentSha256,
 	}
-	headers["Authorization"] = c.sign(method, headers, "/", "lifecycle=")
+	auth, err := c.sign(ctx, method, headers, c.endpoint.URI(bucket, ""), query)
+	if err != nil {
+		return nil, fmt.Errorf(" SetLifecycle Bucket: %s Error: %v", bucket, err)
+	}
+	headers["Authorization"] = auth
 	body := &bytes.Buffer{}
-	header, cErr := internal.CURL(addr, method, headers, strings.NewReader(content), body, nil)
+	header, errBody, cErr := c.http.CURL(ctx, addr, method, headers, strings.NewReader(content), body)
 	if cErr != nil {
 		return nil, fmt.Errorf(" SetLifecycle Bucket: %s Error: %v", bucket, cErr)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" SetLifecycle Bucket: %s StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", bucket, status, reqID, errorMsg.Code, errorMsg.Message)
+		return nil, internal.NewResponseError("SetLifecycle", bucket, "", header, errBody)
 	}
 	return header, nil
 }
 
 // DeleteLifecycle 删除bucket lifecycle
 func (c *Client) DeleteLifecycle(bucket string) (http.Header, error) {
-	host := fmt.Sprintf("%s.%s", bucket, c.host)
-	addr := fmt.Sprintf("http://%s/?lifecycle", host)
+	return c.DeleteLifecycleWithContext(context.Background(), bucket)
+}
+
+// DeleteLifecycleWithContext 删除bucket lifecycle,支持通过ctx取消和设置超时
+func (c *Client) DeleteLifecycleWithContext(ctx context.Context, bucket string) (http.Header, error) {
+	host := c.endpoint.BucketHost(bucket)
+	query := url.Values{"lifecycle": {""}}
+	addr := c.endpoint.URL(bucket, "") + "?" + signer.CanonicalQuery(query)
 	method := "DELETE"
 	date := time.Now().UTC().Format(c.iso8601FormatDateTime)
 	headers := map[string]string{
@@ -405,8 +522,12 @@ func (c *Client) DeleteLifecycle(bucket string) (http.Header, error) {
 		"x-amz-date":           date,
 		"x-amz-content-sha256": c.emptyStringSHA256,
 	}
-	headers["Authorization"] = c.sign(method, headers, "/", "lifecycle=")
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(""), nil, nil)
+	auth, err := c.sign(ctx, method, headers, c.endpoint.URI(bucket, ""), query)
+	if err != nil {
+		return nil, fmt.Errorf(" DeleteLifecycle Bucket: %s Error: %v", bucket, err)
+	}
+	headers["Authorization"] = auth
+	header, _, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), nil)
 	if err != nil {
 		return nil, fmt.Errorf(" DeleteLifecycle Bucket: %s Error: %v", bucket, err)
 	}
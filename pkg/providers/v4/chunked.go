@@ -0,0 +1,128 @@
+package v4
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	streamingPayload   = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	streamingAlgorithm = "AWS4-HMAC-SHA256-PAYLOAD"
+	// 每个分块的数据大小
+	streamingChunkSize = 64 * 1024
+	// 分块头中除长度外的固定部分 ;chunk-signature=<64位签名>\r\n 及数据后的 \r\n
+	streamingChunkOverhead = len(";chunk-signature=") + 64 + 2 + 2
+)
+
+// chunkedLength aws-chunked编码后的请求体长度
+func chunkedLength(size int64) int64 {
+	full, rest := size/streamingChunkSize, size%streamingChunkSize
+	length := full * int64(len(strconv.FormatInt(streamingChunkSize, 16))+streamingChunkOverhead+streamingChunkSize)
+	if rest > 0 {
+		length += int64(len(strconv.FormatInt(rest, 16))+streamingChunkOverhead) + rest
+	}
+	//结尾的空分块
+	return length + int64(1+streamingChunkOverhead)
+}
+
+// chunkedReader 将请求体按aws-chunked编码,每个分块的签名基于上一个分块的签名
+type chunkedReader struct {
+	body      io.Reader
+	size      int64
+	key       []byte
+	date      string
+	scope     string
+	seed      string
+	emptyHash string
+
+	prevSig string
+	chunk   []byte
+	buf     bytes.Buffer
+	done    bool
+	read    int64
+}
+
+// seekableChunkedReader 请求体可Seek时支持回到开头重新编码,以便请求重试
+type seekableChunkedReader struct {
+	*chunkedReader
+	seeker io.ReadSeeker
+	start  int64
+}
+
+// newChunkedReader seedSignature为请求头的签名,请求体可Seek时返回的reader同样可Seek
+func (c *Client) newChunkedReader(body io.Reader, size int64, key []byte, dt time.Time, seedSignature string) io.Reader {
+	r := &chunkedReader{
+		body:      io.LimitReader(body, size),
+		size:      size,
+		key:       key,
+		date:      dt.Format(c.iso8601FormatDateTime),
+		scope:     c.buildCredentialString(dt),
+		seed:      seedSignature,
+		emptyHash: c.emptyStringSHA256,
+		prevSig:   seedSignature,
+		chunk:     make([]byte, streamingChunkSize),
+	}
+	if seeker, ok := body.(io.ReadSeeker); ok {
+		start, err := seeker.Seek(0, io.SeekCurrent)
+		if err == nil {
+			return &seekableChunkedReader{chunkedReader: r, seeker: seeker, start: start}
+		}
+	}
+	return r
+}
+
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	for r.buf.Len() == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(r.body, r.chunk)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return 0, err
+		}
+		r.writeChunk(r.chunk[:n])
+		r.done = n == 0
+	}
+	n, _ := r.buf.Read(p)
+	r.read += int64(n)
+	return n, nil
+}
+
+// writeChunk 签名并写入一个分块,data为空时为结尾的分块
+func (r *chunkedReader) writeChunk(data []byte) {
+	hash := sha256.Sum256(data)
+	strToSign := streamingAlgorithm + "\n" +
+		r.date + "\n" +
+		r.scope + "\n" +
+		r.prevSig + "\n" +
+		r.emptyHash + "\n" +
+		hex.EncodeToString(hash[:])
+	r.prevSig = hex.EncodeToString(hmacSHA256(r.key, []byte(strToSign)))
+	r.buf.WriteString(strconv.FormatInt(int64(len(data)), 16) + ";chunk-signature=" + r.prevSig + "\r\n")
+	r.buf.Write(data)
+	r.buf.WriteString("\r\n")
+}
+
+// Seek 只支持查询当前位置及回到开头
+func (r *seekableChunkedReader) Seek(offset int64, whence int) (int64, error) {
+	switch {
+	case offset == 0 && whence == io.SeekCurrent:
+		return r.read, nil
+	case offset == 0 && whence == io.SeekStart:
+		if _, err := r.seeker.Seek(r.start, io.SeekStart); err != nil {
+			return 0, err
+		}
+		r.body = io.LimitReader(r.seeker, r.size)
+		r.prevSig = r.seed
+		r.buf.Reset()
+		r.done = false
+		r.read = 0
+		return 0, nil
+	}
+	return 0, errors.New("aws-chunked body can only seek to start")
+}
@@ -1,10 +1,20 @@
 package v4
 
+import (
+	"github.com/shideqin/go-s3-sdk/pkg/internal"
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
 // Client 客户端结构
 type Client struct {
-	host            string
-	accessKeyID     string
-	accessKeySecret string
+	endpoint           *internal.Endpoint
+	host               string
+	region             string
+	service            string
+	credentials        s3.CredentialsProvider
+	payloadSigning     s3.PayloadSigning
+	checksum           s3.ChecksumAlgorithm
+	checksumValidation bool
 
 	dateTimeGMT           string
 	iso8601FormatDateTime string
@@ -12,31 +22,52 @@ type Client struct {
 	authHeaderPrefix      string
 	awsV4Request          string
 	emptyStringSHA256     string
+	unsignedPayload       string
+
+	limits internal.Limits
 
-	partMaxSize  int
-	partMinSize  int
-	maxRetryNum  int
-	threadMaxNum int
-	threadMinNum int
+	http *internal.Client
 }
 
-// New 实例化
-func New(host, accessKeyID, accessKeySecret string) *Client {
+// New 实例化,host支持 host[:port] 或 scheme://host[:port][/path] 形式,未指定协议时使用https
+func New(host, accessKeyID, accessKeySecret string, opts ...s3.Option) *Client {
+	cfg := s3.NewConfig(opts...)
+	endpoint := internal.ParseEndpoint(host)
+	endpoint.PathStyle = cfg.PathStyle
+	credentials := cfg.Credentials
+	if credentials == nil {
+		credentials = s3.StaticCredentials(accessKeyID, accessKeySecret, "")
+	}
+	region := cfg.Region
+	if region == "" {
+		region = endpoint.Region()
+	}
 	return &Client{
-		host:            host,
-		accessKeyID:     accessKeyID,
-		accessKeySecret: accessKeySecret,
+		endpoint:       endpoint,
+		host:           endpoint.Host,
+		region:         region,
+		service:        cfg.Service,
+		credentials:    credentials,
+		payloadSigning: cfg.PayloadSigning,
+		checksum:       cfg.Checksum,
 
+		checksumValidation: cfg.ChecksumValidation,
+
+		dateTimeGMT:           "Mon, 02 Jan 2006 15:04:05 GMT",
 		iso8601FormatDateTime: "20060102T150405Z",
 		iso8601FormatDate:     "20060102",
 		authHeaderPrefix:      "AWS4-HMAC-SHA256",
 		awsV4Request:          "aws4_request",
 		emptyStringSHA256:     "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		unsignedPayload:       "UNSIGNED-PAYLOAD",
+
+		limits: internal.NewLimits(cfg),
 
-		partMaxSize:  10 * 1024 * 1024,
-		partMinSize:  1 * 1024 * 1024,
-		maxRetryNum:  10,
-		threadMaxNum: 10,
-		threadMinNum: 1,
+		http: internal.NewClient(cfg),
 	}
 }
+
+// Credentials 客户端使用的凭证提供者,可用于pkg/signer对其他请求签名
+func (c *Client) Credentials() s3.CredentialsProvider {
+	return c.credentials
+}
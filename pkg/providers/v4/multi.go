@@ -2,6 +2,7 @@ package v4
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"encoding/xml"
 	"fmt"
@@ -17,6 +18,7 @@ import (
 
 	"github.com/shideqin/go-s3-sdk/pkg/internal"
 	"github.com/shideqin/go-s3-sdk/pkg/s3"
+	"github.com/shideqin/go-s3-sdk/pkg/signer"
 )
 
 // InitUploadResult 初始化上传结果
@@ -30,26 +32,46 @@ type CompleteUploadResult = s3.CompleteUploadResult
 
 // UploadLargeFile 分块上传文件
 func (c *Client) UploadLargeFile(filePath, bucket, object string, options map[string]string, percentChan chan int) (map[string]interface{}, error) {
+	return c.UploadLargeFileWithContext(context.Background(), filePath, bucket, object, options, percentChan)
+}
+
+// UploadLargeFileWithContext 分块上传文件,支持通过ctx取消和设置超时
+func (c *Client) UploadLargeFileWithContext(ctx context.Context, filePath, bucket, object string, options map[string]string, percentChan chan int) (map[string]interface{}, error) {
+	result, err := c.uploadLargeFile(ctx, filePath, bucket, object, c.limits.MultipartOptions(options), percentChan)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// UploadLargeFileWithOptions 分块上传文件,参数非法时返回s3.ErrInvalidOption
+func (c *Client) UploadLargeFileWithOptions(ctx context.Context, filePath, bucket, object string, opts *s3.MultipartOptions) (*s3.PutResult, error) {
+	return c.uploadLargeFile(ctx, filePath, bucket, object, opts, nil)
+}
+
+func (c *Client) uploadLargeFile(ctx context.Context, filePath, bucket, object string, opts *s3.MultipartOptions, percentChan chan int) (result *s3.PutResult, err error) {
+	if opts == nil {
+		opts = &s3.MultipartOptions{}
+	}
+	start := time.Now()
+	progress := internal.NewProgress(opts.Progress)
+	defer func() {
+		progress.Close(err)
+	}()
+	partSize, err := c.limits.PartSize(opts.PartSize, c.limits.PartMaxSize)
+	if err != nil {
+		return nil, fmt.Errorf(" UploadLargeFile Error: %w", err)
+	}
+	threadNum, err := c.limits.ThreadNum(opts.ThreadNum)
+	if err != nil {
+		return nil, fmt.Errorf(" UploadLargeFile Error: %w", err)
+	}
 	//open本地文件
 	fd, openErr := os.Open(filePath)
 	if openErr != nil {
 		return nil, fmt.Errorf(" UploadLargeFile Open localFile: %s Error: %v", filePath, openErr)
 	}
 	defer fd.Close()
-	var partSize = c.partMaxSize
-	if options["part_size"] != "" {
-		n, _ := strconv.Atoi(options["part_size"])
-		if n <= c.partMaxSize && n >= c.partMinSize {
-			partSize = n
-		}
-	}
-	var threadNum = c.threadMaxNum
-	if options["thread_num"] != "" {
-		n, _ := strconv.Atoi(options["thread_num"])
-		if n <= c.threadMaxNum && n >= c.threadMinNum {
-			threadNum = n
-		}
-	}
 	if object == "" {
 		object = path.Base(filePath)
 	}
@@ -58,25 +80,87 @@ func (c *Client) UploadLargeFile(filePath, bucket, object string, options map[st
 	}
 	fileStat, _ := fd.Stat()
 	fileSize := int(fileStat.Size())
+	//断点文件属于其他上传或本地文件已修改时取消原分块上传并重新上传
+	checkpoint := internal.LoadUploadCheckpoint(opts.Checkpoint)
+	if checkpoint != nil && !checkpoint.Valid(bucket, object, filePath, fileStat) {
+		if checkpoint.Bucket == bucket && checkpoint.Object == object && checkpoint.UploadID != opts.UploadID {
+			_, _ = c.CancelPartWithContext(ctx, bucket, object, checkpoint.UploadID)
+		}
+		checkpoint = nil
+	}
+	//指定的分块上传与断点不一致时以指定的为准
+	if checkpoint != nil && opts.UploadID != "" && checkpoint.UploadID != opts.UploadID {
+		checkpoint = nil
+	}
+	uploadID := opts.UploadID
+	if checkpoint != nil {
+		partSize = checkpoint.PartSize
+		uploadID = checkpoint.UploadID
+	}
+	//获取已上传的分块,断点中的分块上传已不存在时重新上传
+	var uploadedParts []internal.PartInfo
+	if uploadID != "" {
+		list, listErr := c.ListPartsWithContext(ctx, bucket, object, uploadID)
+		if listErr != nil && (opts.UploadID != "" || !s3.IsNotFound(listErr)) {
+			return nil, listErr
+		}
+		if listErr != nil {
+			checkpoint = nil
+			uploadID = ""
+		}
+		parts := internal.NewPartInfos(list)
+		uploadedParts = parts
+		//未指定分块大小时沿用已上传的第一个分块的大小
+		if len(parts) > 0 && parts[0].PartNumber == 1 && checkpoint == nil && opts.PartSize == 0 && parts[0].Size < int64(fileSize) {
+			partSize = int(parts[0].Size)
+		}
+	}
 	var total = (fileSize + partSize - 1) / partSize
+	progress.Start(int64(fileSize), 0)
 	if total < threadNum {
 		threadNum = total
 	}
 	//初化化上传
-	initUpload, initErr := c.InitUpload(bucket, object, map[string]string{"disposition": options["disposition"], "acl": options["acl"]})
-	if initErr != nil {
-		return nil, initErr
+	if uploadID == "" {
+		initUpload, initErr := c.InitUploadWithOptions(ctx, bucket, object, &s3.PutOptions{ACL: opts.ACL, Disposition: opts.Disposition})
+		if initErr != nil {
+			return nil, initErr
+		}
+		uploadID = initUpload.UploadID
+	}
+	var knownParts []string
+	if checkpoint != nil {
+		knownParts = checkpoint.Parts
+	} else {
+		checkpoint = internal.NewUploadCheckpoint(opts.Checkpoint, bucket, object, filePath, fileStat, partSize, uploadID)
+	}
+	//跳过已上传且与本地文件一致的分块
+	uploadPartList, checksumList, err := internal.ResumeParts(uploadedParts, knownParts, fd, int64(fileSize), partSize)
+	if err != nil {
+		return nil, fmt.Errorf(" UploadLargeFile Read localFile: %s Error: %v", filePath, err)
+	}
+	if err = checkpoint.SetParts(uploadPartList); err != nil {
+		return nil, fmt.Errorf(" UploadLargeFile Save checkpoint: %s Error: %v", opts.Checkpoint, err)
 	}
-	var uploadPartList = make([]string, total)
 	var queueMaxSize = make(chan bool, threadNum)
 	defer close(queueMaxSize)
 	var uploadExit bool
 	var partErr error
 	var wg sync.WaitGroup
 	for partNum := 0; partNum < total; partNum++ {
+		if ctx.Err() != nil {
+			partErr = ctx.Err()
+			break
+		}
 		if uploadExit {
 			break
 		}
+		//跳过已上传的分块
+		if uploadPartList[partNum] != "" {
+			progress.Transferred(int64(min(partSize, fileSize-partNum*partSize)))
+			internal.SendPercent(percentChan, total)
+			continue
+		}
 		wg.Add(1)
 		queueMaxSize <- true
 		go func(partNum int, fd *os.File) {
@@ -92,25 +176,21 @@ func (c *Client) UploadLargeFile(filePath, bucket, object string, options map[st
 			if fileSize-offset < num {
 				num = fileSize - offset
 			}
-			for i := 0; i < c.maxRetryNum; i++ {
-				partReader := io.NewSectionReader(fd, int64(offset), int64(num))
-				partReaderSize := int(partReader.Size())
-				uploadPart, upErr := c.UploadPart(partReader, partReaderSize, bucket, object, partNum+1, initUpload.UploadID)
-				if upErr != nil {
-					partErr = upErr
-					continue
-				}
-				partErr = nil
-				uploadPartList[partNum] = uploadPart.Get("Etag")
-				break
+			partReader := io.NewSectionReader(fd, int64(offset), int64(num))
+			partReaderSize := int(partReader.Size())
+			uploadPart, upErr := c.UploadPartWithOptions(ctx, partReader, partReaderSize, bucket, object, partNum+1, uploadID, &s3.PartOptions{Progress: progress})
+			if upErr != nil {
+				partErr = upErr
+				return
 			}
-			if partErr != nil {
+			uploadPartList[partNum] = uploadPart.Get("Etag")
+			checksumList[partNum] = internal.ChecksumElement(internal.ResponseChecksum(uploadPart))
+			if cpErr := checkpoint.Done(partNum+1, uploadPart.Get("Etag")); cpErr != nil {
+				partErr = fmt.Errorf(" UploadLargeFile Save checkpoint: %s Error: %v", opts.Checkpoint, cpErr)
 				return
 			}
 			//进度条
-			if percentChan != nil {
-				percentChan <- total
-			}
+			internal.SendPercent(percentChan, total)
 		}(partNum, fd)
 	}
 	wg.Wait()
@@ -120,35 +200,63 @@ func (c *Client) UploadLargeFile(filePath, bucket, object string, options map[st
 	//上传完成
 	completeUploadInfo := "<CompleteMultipartUpload>"
 	for partNum, Etag := range uploadPartList {
-		completeUploadInfo += fmt.Sprintf("<Part><PartNumber>%d</PartNumber><ETag>%s</ETag></Part>", partNum+1, Etag)
+		completeUploadInfo += fmt.Sprintf("<Part><PartNumber>%d</PartNumber><ETag>%s</ETag>%s</Part>", partNum+1, Etag, checksumList[partNum])
 	}
 	completeUploadInfo += "</CompleteMultipartUpload>"
-	return c.CompleteUpload([]byte(completeUploadInfo), bucket, object, initUpload.UploadID, fileSize)
+	result, err = c.CompleteUploadWithOptions(ctx, []byte(completeUploadInfo), bucket, object, uploadID, &s3.CompleteOptions{ObjectSize: int64(fileSize)})
+	if err != nil {
+		return nil, err
+	}
+	_ = checkpoint.Remove()
+	result.Duration = time.Since(start)
+	return result, nil
 }
 
 // CopyLargeFile 分块复制文件
 func (c *Client) CopyLargeFile(bucket, object, source string, options map[string]string, percentChan chan int, exitChan <-chan bool) (map[string]interface{}, error) {
+	ctx, cancel := internal.ExitContext(exitChan)
+	defer cancel()
+	return c.CopyLargeFileWithContext(ctx, bucket, object, source, options, percentChan)
+}
+
+// CopyLargeFileWithContext 分块复制文件,支持通过ctx取消和设置超时
+func (c *Client) CopyLargeFileWithContext(ctx context.Context, bucket, object, source string, options map[string]string, percentChan chan int) (map[string]interface{}, error) {
+	result, err := c.copyLargeFile(ctx, bucket, object, source, c.limits.MultipartOptions(options), percentChan)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// CopyLargeFileWithOptions 分块复制文件,参数非法时返回s3.ErrInvalidOption
+func (c *Client) CopyLargeFileWithOptions(ctx context.Context, bucket, object, source string, opts *s3.MultipartOptions) (*s3.PutResult, error) {
+	return c.copyLargeFile(ctx, bucket, object, source, opts, nil)
+}
+
+func (c *Client) copyLargeFile(ctx context.Context, bucket, object, source string, opts *s3.MultipartOptions, percentChan chan int) (result *s3.PutResult, err error) {
+	if opts == nil {
+		opts = &s3.MultipartOptions{}
+	}
+	start := time.Now()
+	progress := internal.NewProgress(opts.Progress)
+	defer func() {
+		progress.Close(err)
+	}()
+	partSize, err := c.limits.PartSize(opts.PartSize, c.limits.PartMaxSize)
+	if err != nil {
+		return nil, fmt.Errorf(" CopyLargeFile Error: %w", err)
+	}
+	threadNum, err := c.limits.ThreadNum(opts.ThreadNum)
+	if err != nil {
+		return nil, fmt.Errorf(" CopyLargeFile Error: %w", err)
+	}
 	tmpSourceInfo := strings.Split(source, "/")
 	sourceBucket := tmpSourceInfo[1]
 	sourceObject := strings.Join(tmpSourceInfo[2:], "/")
-	sourceHead, headErr := c.Head(sourceBucket, sourceObject)
+	sourceHead, headErr := c.HeadWithContext(ctx, sourceBucket, sourceObject)
 	if headErr != nil {
 		return nil, headErr
 	}
-	var partSize = c.partMaxSize
-	if options["part_size"] != "" {
-		n, _ := strconv.Atoi(options["part_size"])
-		if n <= c.partMaxSize && n >= c.partMinSize {
-			partSize = n
-		}
-	}
-	var threadNum = c.threadMaxNum
-	if options["thread_num"] != "" {
-		n, _ := strconv.Atoi(options["thread_num"])
-		if n <= c.threadMaxNum && n >= c.threadMinNum {
-			threadNum = n
-		}
-	}
 
 	if object == "" {
 		object = path.Base(sourceObject)
@@ -157,37 +265,37 @@ func (c *Client) CopyLargeFile(bucket, object, source string, options map[string
 		object = path.Dir(object) + "/" + path.Base(sourceObject)
 	}
 	var objectSize, _ = strconv.Atoi(sourceHead.Get("Content-Length"))
+	//继续指定的分块上传时获取已复制的分块
+	var copiedParts []internal.PartInfo
+	if opts.UploadID != "" {
+		list, listErr := c.ListPartsWithContext(ctx, bucket, object, opts.UploadID)
+		if listErr != nil {
+			return nil, listErr
+		}
+		parts := internal.NewPartInfos(list)
+		copiedParts = parts
+		//未指定分块大小时沿用已复制的第一个分块的大小
+		if len(parts) > 0 && parts[0].PartNumber == 1 && opts.PartSize == 0 && parts[0].Size < int64(objectSize) {
+			partSize = int(parts[0].Size)
+		}
+	}
 	var total = (objectSize + partSize - 1) / partSize
+	progress.Start(int64(objectSize), 0)
 	if total < threadNum {
 		threadNum = total
 	}
 
 	//初化化上传
-	initUpload, initErr := c.InitUpload(bucket, object, map[string]string{"disposition": options["disposition"], "acl": options["acl"]})
-	if initErr != nil {
-		return nil, initErr
-	}
-	var copyPartList = make([]string, total)
-
-	//取消处理
-	var copyCancel bool
-	var copyExitChan = make(chan bool)
-	defer close(copyExitChan)
-	if exitChan != nil {
-		go func() {
-			for {
-				exit, ok := <-exitChan
-				if !ok {
-					break
-				}
-				if exit {
-					copyCancel = true
-					copyExitChan <- true
-					break
-				}
-			}
-		}()
+	uploadID := opts.UploadID
+	if uploadID == "" {
+		initUpload, initErr := c.InitUploadWithOptions(ctx, bucket, object, &s3.PutOptions{ACL: opts.ACL, Disposition: opts.Disposition})
+		if initErr != nil {
+			return nil, initErr
+		}
+		uploadID = initUpload.UploadID
 	}
+	//大小一致的已复制分块不再复制
+	copyPartList, checksumList, _ := internal.ResumeParts(copiedParts, nil, nil, int64(objectSize), partSize)
 
 	//copy分片
 	var queueMaxSize = make(chan bool, threadNum)
@@ -196,9 +304,19 @@ func (c *Client) CopyLargeFile(bucket, object, source string, options map[string
 	var copyExit bool
 	var wg sync.WaitGroup
 	for partNum := 0; partNum < total; partNum++ {
+		if ctx.Err() != nil {
+			partErr = ctx.Err()
+			break
+		}
 		if copyExit {
 			break
 		}
+		//跳过已复制的分块
+		if copyPartList[partNum] != "" {
+			progress.Transferred(int64(min(partSize, objectSize-partNum*partSize)))
+			internal.SendPercent(percentChan, total)
+			continue
+		}
 		wg.Add(1)
 		queueMaxSize <- true
 		go func(partNum int) {
@@ -216,27 +334,16 @@ func (c *Client) CopyLargeFile(bucket, object, source string, options map[string
 				tmpEnd = tmpStart + objectSize%partSize - 1
 			}
 			partRange := fmt.Sprintf("bytes=%d-%d", tmpStart, tmpEnd)
-			for i := 0; i < c.maxRetryNum; i++ {
-				if copyCancel {
-					partErr = fmt.Errorf("canceled")
-					break
-				}
-				copyPart, copyErr := c.CopyPart(partRange, bucket, object, source, partNum+1, initUpload.UploadID, copyExitChan)
-				if copyErr != nil {
-					partErr = copyErr
-					continue
-				}
-				partErr = nil
-				copyPartList[partNum] = copyPart["Etag"]
-				break
-			}
-			if partErr != nil {
+			copyPart, copyErr := c.CopyPartWithContext(ctx, partRange, bucket, object, source, partNum+1, uploadID)
+			if copyErr != nil {
+				partErr = copyErr
 				return
 			}
+			copyPartList[partNum] = copyPart["Etag"]
+			checksumList[partNum] = internal.ChecksumElement(s3.ChecksumAlgorithm(copyPart["ChecksumAlgorithm"]), copyPart["Checksum"])
+			progress.Transferred(int64(tmpEnd - tmpStart + 1))
 			//进度条
-			if percentChan != nil {
-				percentChan <- total
-			}
+			internal.SendPercent(percentChan, total)
 		}(partNum)
 	}
 	wg.Wait()
@@ -246,47 +353,97 @@ func (c *Client) CopyLargeFile(bucket, object, source string, options map[string
 	//copy完成
 	completeCopyInfo := "<CompleteMultipartUpload>"
 	for partNum, Etag := range copyPartList {
-		completeCopyInfo += fmt.Sprintf("<Part><PartNumber>%d</PartNumber><ETag>%s</ETag></Part>", partNum+1, Etag)
+		completeCopyInfo += fmt.Sprintf("<Part><PartNumber>%d</PartNumber><ETag>%s</ETag>%s</Part>", partNum+1, Etag, checksumList[partNum])
 	}
 	completeCopyInfo += "</CompleteMultipartUpload>"
-	return c.CompleteUpload([]byte(completeCopyInfo), bucket, object, initUpload.UploadID, objectSize)
+	result, err = c.CompleteUploadWithOptions(ctx, []byte(completeCopyInfo), bucket, object, uploadID, &s3.CompleteOptions{ObjectSize: int64(objectSize)})
+	if err != nil {
+		return nil, err
+	}
+	result.Duration = time.Since(start)
+	return result, nil
 }
 
 // MoveLargeFile 移动文件
 func (c *Client) MoveLargeFile(bucket, object, source string, options map[string]string) (map[string]interface{}, error) {
+	return c.MoveLargeFileWithContext(context.Background(), bucket, object, source, options)
+}
+
+// MoveLargeFileWithContext 移动文件,支持通过ctx取消和设置超时
+func (c *Client) MoveLargeFileWithContext(ctx context.Context, bucket, object, source string, options map[string]string) (map[string]interface{}, error) {
+	result, err := c.MoveLargeFileWithOptions(ctx, bucket, object, source, c.limits.MultipartOptions(options))
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// MoveLargeFileWithOptions 移动文件,参数非法时返回s3.ErrInvalidOption
+func (c *Client) MoveLargeFileWithOptions(ctx context.Context, bucket, object, source string, opts *s3.MultipartOptions) (*s3.PutResult, error) {
+	if opts == nil {
+		opts = &s3.MultipartOptions{}
+	}
+	start := time.Now()
 	tmpSourceInfo := strings.Split(source, "/")
 	sourceBucket := tmpSourceInfo[1]
 	sourceObject := strings.Join(tmpSourceInfo[2:], "/")
 	if bucket == sourceBucket && object == sourceObject {
 		return nil, fmt.Errorf("move soure-object and target-object same not allowed")
 	}
-	sourceHead, hErr := c.Head(sourceBucket, sourceObject)
+	sourceHead, hErr := c.HeadWithContext(ctx, sourceBucket, sourceObject)
 	if hErr != nil {
 		return nil, hErr
 	}
 	var disposition = internal.GetDisposition(sourceHead.Get("Content-Disposition"))
-	copied, cErr := c.CopyLargeFile(bucket, object, source, map[string]string{"thread_num": options["thread_num"], "part_size": options["part_size"], "disposition": disposition, "acl": options["acl"]}, nil, nil)
+	copied, cErr := c.CopyLargeFileWithOptions(ctx, bucket, object, source, &s3.MultipartOptions{ACL: opts.ACL, Disposition: disposition, PartSize: opts.PartSize, ThreadNum: opts.ThreadNum})
 	if cErr != nil {
 		return nil, cErr
 	}
 	//删除源文件
-	for i := 0; i < c.maxRetryNum; i++ {
-		_, cErr = c.Delete(sourceBucket, sourceObject)
-		if cErr != nil {
-			continue
-		}
-		break
-	}
+	_, cErr = c.DeleteWithContext(ctx, sourceBucket, sourceObject)
 	if cErr != nil {
 		return nil, cErr
 	}
+	copied.Duration = time.Since(start)
 	return copied, nil
 }
 
+// NewWriter 流式上传,用于大小未知的数据,参数非法时返回s3.ErrInvalidOption
+// 数据按分块大小缓冲,超过一个分块时并发分块上传,否则Close时使用Put上传;CloseWithError取消分块上传
+func (c *Client) NewWriter(ctx context.Context, bucket, object string, opts *s3.MultipartOptions) (s3.ObjectWriter, error) {
+	if opts == nil {
+		opts = &s3.MultipartOptions{}
+	}
+	partSize, err := c.limits.PartSize(opts.PartSize, c.limits.PartMaxSize)
+	if err != nil {
+		return nil, fmt.Errorf(" NewWriter Error: %w", err)
+	}
+	threadNum, err := c.limits.ThreadNum(opts.ThreadNum)
+	if err != nil {
+		return nil, fmt.Errorf(" NewWriter Error: %w", err)
+	}
+	return internal.NewObjectWriter(ctx, c, bucket, object, opts, partSize, threadNum), nil
+}
+
+// InitUpload 初始化分块上传
 func (c *Client) InitUpload(bucket, object string, options map[string]string) (*InitUploadResult, error) {
-	nObject := url.QueryEscape(object)
-	host := fmt.Sprintf("%s.%s", bucket, c.host)
-	addr := fmt.Sprintf("http://%s/%s?uploads", host, nObject)
+	return c.InitUploadWithContext(context.Background(), bucket, object, options)
+}
+
+// InitUploadWithContext 初始化分块上传,支持通过ctx取消和设置超时
+func (c *Client) InitUploadWithContext(ctx context.Context, bucket, object string, options map[string]string) (*InitUploadResult, error) {
+	return c.InitUploadWithOptions(ctx, bucket, object, internal.PutOptions(options))
+}
+
+// InitUploadWithOptions 初始化分块上传,参数非法时返回s3.ErrInvalidOption
+func (c *Client) InitUploadWithOptions(ctx context.Context, bucket, object string, opts *s3.PutOptions) (*InitUploadResult, error) {
+	if opts == nil {
+		opts = &s3.PutOptions{}
+	}
+	nObject := signer.EscapePath(object)
+	host := c.endpoint.BucketHost(bucket)
+	query := url.Values{"uploads": {""}}
+	addr := c.endpoint.URL(bucket, nObject) + "?" + signer.CanonicalQuery(query)
 	method := "POST"
 	date := time.Now().UTC().Format(c.iso8601FormatDateTime)
 	headers := map[string]string{
@@ -294,24 +451,26 @@ func (c *Client) InitUpload(bucket, object string, options map[string]string) (*
 		"x-amz-date":           date,
 		"x-amz-content-sha256": c.emptyStringSHA256,
 	}
-	if options["acl"] != "" {
-		headers["x-amz-acl"] = options["acl"]
+	if opts.ACL != "" {
+		headers["x-amz-acl"] = opts.ACL
+	}
+	internal.SetChecksumAlgorithm(headers, c.checksum)
+	auth, err := c.sign(ctx, method, headers, c.endpoint.URI(bucket, nObject), query)
+	if err != nil {
+		return nil, fmt.Errorf(" InitUpload Object: %s Error: %v", object, err)
 	}
-	headers["Authorization"] = c.sign(method, headers, "/"+nObject, "uploads=")
-	if options["disposition"] != "" {
-		headers["Content-Disposition"] = fmt.Sprintf(`attachment; filename="%s"`, options["disposition"])
+	headers["Authorization"] = auth
+	if opts.Disposition != "" {
+		headers["Content-Disposition"] = fmt.Sprintf(`attachment; filename="%s"`, opts.Disposition)
 	}
 	body := &bytes.Buffer{}
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(""), body, nil)
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), body)
 	if err != nil {
 		return nil, fmt.Errorf(" InitUpload Object: %s Error: %v", object, err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" InitUpload Object: %s StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", object, status, reqID, errorMsg.Code, errorMsg.Message)
+		return nil, internal.NewResponseError("InitUpload", bucket, object, header, errBody)
 	}
 	var initUpload = &InitUploadResult{}
 	if err = xml.Unmarshal(body.Bytes(), initUpload); err != nil {
@@ -320,41 +479,142 @@ func (c *Client) InitUpload(bucket, object string, options map[string]string) (*
 	return initUpload, nil
 }
 
+// UploadPart 上传分块
 func (c *Client) UploadPart(content io.Reader, bodySize int, bucket, object string, partNumber int, uploadID string) (http.Header, error) {
-	nObject := url.QueryEscape(object)
-	subObject := fmt.Sprintf("partNumber=%d&uploadId=%s", partNumber, uploadID)
-	host := fmt.Sprintf("%s.%s", bucket, c.host)
-	addr := fmt.Sprintf("http://%s/%s?%s", host, nObject, subObject)
+	return c.UploadPartWithContext(context.Background(), content, bodySize, bucket, object, partNumber, uploadID)
+}
+
+// UploadPartWithContext 上传分块,支持通过ctx取消和设置超时
+func (c *Client) UploadPartWithContext(ctx context.Context, content io.Reader, bodySize int, bucket, object string, partNumber int, uploadID string) (http.Header, error) {
+	return c.UploadPartWithOptions(ctx, content, bodySize, bucket, object, partNumber, uploadID, nil)
+}
+
+// UploadPartWithOptions 上传分块,支持进度监听
+func (c *Client) UploadPartWithOptions(ctx context.Context, content io.Reader, bodySize int, bucket, object string, partNumber int, uploadID string, opts *s3.PartOptions) (header http.Header, err error) {
+	if opts == nil {
+		opts = &s3.PartOptions{}
+	}
+	progress := internal.NewProgress(opts.Progress)
+	defer func() {
+		progress.Close(err)
+	}()
+	progress.Start(int64(bodySize), 0)
+	nObject := signer.EscapePath(object)
+	query := url.Values{"partNumber": {strconv.Itoa(partNumber)}, "uploadId": {uploadID}}
+	host := c.endpoint.BucketHost(bucket)
+	addr := c.endpoint.URL(bucket, nObject) + "?" + signer.CanonicalQuery(query)
 	method := "PUT"
 	date := time.Now().UTC().Format(c.iso8601FormatDateTime)
-	contentSha256 := hex.EncodeToString(hashSHA256Reader(content))
 	headers := map[string]string{
-		"host":                 host,
-		"x-amz-date":           date,
-		"x-amz-content-sha256": contentSha256,
+		"host":       host,
+		"x-amz-date": date,
+	}
+	if err = internal.SetChecksum(headers, c.checksum, content); err != nil {
+		return nil, fmt.Errorf(" UploadPart Object: %s Error: %w", object, err)
+	}
+	auth, content, err := c.signBody(ctx, method, headers, c.endpoint.URI(bucket, nObject), query, content, bodySize)
+	if err != nil {
+		return nil, fmt.Errorf(" UploadPart Object: %s Error: %v", object, err)
 	}
-	headers["Authorization"] = c.sign(method, headers, "/"+nObject, subObject)
-	headers["Content-Length"] = fmt.Sprintf("%d", bodySize)
+	headers["Authorization"] = auth
 	body := &bytes.Buffer{}
-	header, err := internal.CURL(addr, method, headers, content, body, nil)
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, internal.ProgressReader(content, progress, int64(bodySize)), body)
 	if err != nil {
 		return nil, fmt.Errorf(" UploadPart Object: %s Error: %v", object, err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" UploadPart Object: %s StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", object, status, reqID, errorMsg.Code, errorMsg.Message)
+		return nil, internal.NewResponseError("UploadPart", bucket, object, header, errBody)
 	}
 	return header, nil
 }
 
+// ListUploadPartsResult 获取已上传分块结果
+type ListUploadPartsResult = s3.ListUploadPartsResult
+
+// ListPartsWithContext 获取分块上传中已上传的全部分块,分页获取后合并为一个结果,支持通过ctx取消和设置超时
+func (c *Client) ListPartsWithContext(ctx context.Context, bucket, object, uploadID string) (*ListUploadPartsResult, error) {
+	var result *ListUploadPartsResult
+	opts := &s3.ListPartsOptions{}
+	for {
+		list, err := c.ListPartsWithOptions(ctx, bucket, object, uploadID, opts)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			result = list
+		} else {
+			result.Part = append(result.Part, list.Part...)
+		}
+		if list.IsTruncated != "true" || list.NextPartNumberMarker <= opts.PartNumberMarker {
+			result.IsTruncated = "false"
+			result.NextPartNumberMarker = 0
+			return result, nil
+		}
+		opts.PartNumberMarker = list.NextPartNumberMarker
+	}
+}
+
+// ListPartsWithOptions 分页获取已上传的分块,参数非法时返回s3.ErrInvalidOption
+func (c *Client) ListPartsWithOptions(ctx context.Context, bucket, object, uploadID string, opts *s3.ListPartsOptions) (*ListUploadPartsResult, error) {
+	if opts == nil {
+		opts = &s3.ListPartsOptions{}
+	}
+	query := url.Values{"uploadId": {uploadID}}
+	if opts.PartNumberMarker < 0 {
+		return nil, fmt.Errorf(" ListParts Object: %s Error: %w: PartNumberMarker %d must not be negative", object, s3.ErrInvalidOption, opts.PartNumberMarker)
+	}
+	if opts.PartNumberMarker > 0 {
+		query.Set("part-number-marker", strconv.Itoa(opts.PartNumberMarker))
+	}
+	if opts.MaxParts < 0 {
+		return nil, fmt.Errorf(" ListParts Object: %s Error: %w: MaxParts %d must not be negative", object, s3.ErrInvalidOption, opts.MaxParts)
+	}
+	if opts.MaxParts > 0 {
+		query.Set("max-parts", strconv.Itoa(opts.MaxParts))
+	}
+	nObject := signer.EscapePath(object)
+	host := c.endpoint.BucketHost(bucket)
+	addr := c.endpoint.URL(bucket, nObject) + "?" + signer.CanonicalQuery(query)
+	method := "GET"
+	date := time.Now().UTC().Format(c.iso8601FormatDateTime)
+	headers := map[string]string{
+		"host":                 host,
+		"x-amz-date":           date,
+		"x-amz-content-sha256": c.emptyStringSHA256,
+	}
+	auth, err := c.sign(ctx, method, headers, c.endpoint.URI(bucket, nObject), query)
+	if err != nil {
+		return nil, fmt.Errorf(" ListParts Object: %s Error: %v", object, err)
+	}
+	headers["Authorization"] = auth
+	body := &bytes.Buffer{}
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), body)
+	if err != nil {
+		return nil, fmt.Errorf(" ListParts Object: %s Error: %v", object, err)
+	}
+	var status = header.Get("StatusCode")
+	if status != "200" {
+		return nil, internal.NewResponseError("ListParts", bucket, object, header, errBody)
+	}
+	var listParts = &ListUploadPartsResult{}
+	if err = xml.Unmarshal(body.Bytes(), listParts); err != nil {
+		return nil, fmt.Errorf(" ListParts Object: %s Error: %v", object, err)
+	}
+	return listParts, nil
+}
+
+// CancelPart 取消分块上传
 func (c *Client) CancelPart(bucket, object string, uploadID string) (http.Header, error) {
-	nObject := url.QueryEscape(object)
-	subObject := fmt.Sprintf("uploadId=%s", uploadID)
-	host := fmt.Sprintf("%s.%s", bucket, c.host)
-	addr := fmt.Sprintf("http://%s/%s?%s", host, nObject, subObject)
+	return c.CancelPartWithContext(context.Background(), bucket, object, uploadID)
+}
+
+// CancelPartWithContext 取消分块上传,支持通过ctx取消和设置超时
+func (c *Client) CancelPartWithContext(ctx context.Context, bucket, object string, uploadID string) (http.Header, error) {
+	nObject := signer.EscapePath(object)
+	query := url.Values{"uploadId": {uploadID}}
+	host := c.endpoint.BucketHost(bucket)
+	addr := c.endpoint.URL(bucket, nObject) + "?" + signer.CanonicalQuery(query)
 	method := "DELETE"
 	date := time.Now().UTC().Format(c.iso8601FormatDateTime)
 	headers := map[string]string{
@@ -362,53 +622,91 @@ func (c *Client) CancelPart(bucket, object string, uploadID string) (http.Header
 		"x-amz-date":           date,
 		"x-amz-content-sha256": c.emptyStringSHA256,
 	}
-	headers["Authorization"] = c.sign(method, headers, "/"+nObject, subObject)
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(""), nil, nil)
+	auth, err := c.sign(ctx, method, headers, c.endpoint.URI(bucket, nObject), query)
+	if err != nil {
+		return nil, fmt.Errorf(" CancelPart Object: %s Error: %v", object, err)
+	}
+	headers["Authorization"] = auth
+	body := &bytes.Buffer{}
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), body)
 	if err != nil {
 		return nil, fmt.Errorf(" CancelPart Object: %s Error: %v", object, err)
 	}
+	var status = header.Get("StatusCode")
+	if status != "200" && status != "204" {
+		return nil, internal.NewResponseError("CancelPart", bucket, object, header, errBody)
+	}
 	return header, nil
 }
 
+// CopyPart 复制分块
 func (c *Client) CopyPart(partRange, bucket, object, source string, partNumber int, uploadID string, copyExitChan <-chan bool) (map[string]string, error) {
-	nObject := url.QueryEscape(object)
-	subObject := fmt.Sprintf("partNumber=%d&uploadId=%s", partNumber, uploadID)
-	host := fmt.Sprintf("%s.%s", bucket, c.host)
-	addr := fmt.Sprintf("http://%s/%s?%s", host, nObject, subObject)
+	ctx, cancel := internal.ExitContext(copyExitChan)
+	defer cancel()
+	return c.CopyPartWithContext(ctx, partRange, bucket, object, source, partNumber, uploadID)
+}
+
+// CopyPartWithContext 复制分块,支持通过ctx取消和设置超时
+func (c *Client) CopyPartWithContext(ctx context.Context, partRange, bucket, object, source string, partNumber int, uploadID string) (map[string]string, error) {
+	nObject := signer.EscapePath(object)
+	query := url.Values{"partNumber": {strconv.Itoa(partNumber)}, "uploadId": {uploadID}}
+	host := c.endpoint.BucketHost(bucket)
+	addr := c.endpoint.URL(bucket, nObject) + "?" + signer.CanonicalQuery(query)
 	method := "PUT"
 	date := time.Now().UTC().Format(c.iso8601FormatDateTime)
 	headers := map[string]string{
 		"host":                    host,
 		"x-amz-date":              date,
 		"x-amz-content-sha256":    c.emptyStringSHA256,
-		"x-amz-copy-source":       source,
+		"x-amz-copy-source":       signer.EscapePath(source),
 		"x-amz-copy-source-range": partRange,
 	}
-	headers["Authorization"] = c.sign(method, headers, "/"+nObject, subObject)
+	auth, err := c.sign(ctx, method, headers, c.endpoint.URI(bucket, nObject), query)
+	if err != nil {
+		return nil, fmt.Errorf(" CopyPart Object: %s Error: %v", object, err)
+	}
+	headers["Authorization"] = auth
 	body := &bytes.Buffer{}
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(""), body, copyExitChan)
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), body)
 	if err != nil {
 		return nil, fmt.Errorf(" CopyPart Object: %s Error: %v", object, err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" CopyPart Object: %s StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", object, status, reqID, errorMsg.Code, errorMsg.Message)
+		return nil, internal.NewResponseError("CopyPart", bucket, object, header, errBody)
 	}
 	var copyPart = &CopyPartResult{}
 	if err := xml.Unmarshal(body.Bytes(), copyPart); err != nil {
 		return nil, fmt.Errorf(" CopyPart Object: %s Error: %v", object, err)
 	}
-	return map[string]string{"Etag": copyPart.ETag}, nil
+	algorithm, checksum := internal.PartChecksum(copyPart.PartChecksum)
+	return map[string]string{"Etag": copyPart.ETag, "ChecksumAlgorithm": string(algorithm), "Checksum": checksum}, nil
 }
 
+// CompleteUpload 完成分块上传
 func (c *Client) CompleteUpload(content []byte, bucket, object, uploadID string, objectSize int) (map[string]interface{}, error) {
-	nObject := url.QueryEscape(object)
-	subObject := fmt.Sprintf("uploadId=%s", uploadID)
-	host := fmt.Sprintf("%s.%s", bucket, c.host)
-	addr := fmt.Sprintf("http://%s/%s?%s", host, nObject, subObject)
+	return c.CompleteUploadWithContext(context.Background(), content, bucket, object, uploadID, objectSize)
+}
+
+// CompleteUploadWithContext 完成分块上传,支持通过ctx取消和设置超时
+func (c *Client) CompleteUploadWithContext(ctx context.Context, content []byte, bucket, object, uploadID string, objectSize int) (map[string]interface{}, error) {
+	result, err := c.CompleteUploadWithOptions(ctx, content, bucket, object, uploadID, &s3.CompleteOptions{ObjectSize: int64(objectSize)})
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// CompleteUploadWithOptions 完成分块上传,返回类型化结果
+func (c *Client) CompleteUploadWithOptions(ctx context.Context, content []byte, bucket, object, uploadID string, opts *s3.CompleteOptions) (*s3.PutResult, error) {
+	if opts == nil {
+		opts = &s3.CompleteOptions{}
+	}
+	start := time.Now()
+	nObject := signer.EscapePath(object)
+	query := url.Values{"uploadId": {uploadID}}
+	host := c.endpoint.BucketHost(bucket)
+	addr := c.endpoint.URL(bucket, nObject) + "?" + signer.CanonicalQuery(query)
 	method := "POST"
 	contentSha256 := hex.EncodeToString(hashSHA256(content))
 	date := time.Now().UTC().Format(c.iso8601FormatDateTime)
@@ -417,28 +715,32 @@ func (c *Client) CompleteUpload(content []byte, bucket, object, uploadID string,
 		"x-amz-date":           date,
 		"x-amz-content-sha256": contentSha256,
 	}
-	headers["Authorization"] = c.sign(method, headers, "/"+nObject, subObject)
+	auth, err := c.sign(ctx, method, headers, c.endpoint.URI(bucket, nObject), query)
+	if err != nil {
+		return nil, fmt.Errorf(" CompleteUpload Object: %s Error: %v", object, err)
+	}
+	headers["Authorization"] = auth
 	body := &bytes.Buffer{}
-	header, err := internal.CURL(addr, method, headers, bytes.NewReader(content), body, nil)
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, bytes.NewReader(content), body)
 	if err != nil {
 		return nil, fmt.Errorf(" CompleteUpload Object: %s Error: %v", object, err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" CompleteUpload Object: %s StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", object, status, reqID, errorMsg.Code, errorMsg.Message)
+		return nil, internal.NewResponseError("CompleteUpload", bucket, object, header, errBody)
 	}
 	var completeUpload = &CompleteUploadResult{}
 	if err = xml.Unmarshal(body.Bytes(), completeUpload); err != nil {
 		return nil, fmt.Errorf(" CompleteUpload Object: %s Error: %v", object, err)
 	}
-	return map[string]interface{}{
-		"Location": fmt.Sprintf("http://%s.%s/%s", bucket, c.host, object),
-		"Bucket":   completeUpload.Bucket,
-		"Key":      completeUpload.Key,
-		"ETag":     completeUpload.ETag,
-		"Size":     objectSize,
+	return &s3.PutResult{
+		Bucket:     completeUpload.Bucket,
+		Key:        completeUpload.Key,
+		ETag:       completeUpload.ETag,
+		Size:       opts.ObjectSize,
+		Location:   c.endpoint.URL(bucket, object),
+		StatusCode: http.StatusOK,
+		RequestID:  header.Get("X-Amz-Request-Id"),
+		Duration:   time.Since(start),
 	}, nil
 }
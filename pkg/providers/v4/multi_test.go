@@ -0,0 +1,201 @@
+package v4
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
+func TestNewWriterSinglePut(t *testing.T) {
+	f, srv := newFakeS3(t)
+	c := newTestClient(srv)
+	w, err := c.NewWriter(context.Background(), "bucket", "small.txt", &s3.MultipartOptions{PartSize: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 不超过一个分块时Close使用Put上传
+	for _, s := range []string{"hel", "lo"} {
+		if n, err := w.Write([]byte(s)); err != nil || n != len(s) {
+			t.Fatalf("Write = %d, %v", n, err)
+		}
+	}
+	if w.Result() != nil {
+		t.Error("Result before Close != nil")
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := f.object("bucket/small.txt"); string(data) != "hello" {
+		t.Errorf("object = %q", data)
+	}
+	if n := f.requestsMatching(http.MethodPost, "uploads"); n != 0 {
+		t.Errorf("InitUpload requests = %d, want 0", n)
+	}
+	if result := w.Result(); result == nil || result.Size != 5 || result.ETag != etag([]byte("hello")) {
+		t.Errorf("Result = %+v", result)
+	}
+}
+
+func TestNewWriterMultipart(t *testing.T) {
+	f, srv := newFakeS3(t)
+	c := newTestClient(srv)
+	var mu sync.Mutex
+	var events []*s3.ProgressEvent
+	w, err := c.NewWriter(context.Background(), "bucket", "large.bin", &s3.MultipartOptions{
+		PartSize:  4,
+		ThreadNum: 2,
+		Progress: s3.ProgressFunc(func(event *s3.ProgressEvent) {
+			mu.Lock()
+			events = append(events, event)
+			mu.Unlock()
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := "0123456789"
+	if _, err = io.Copy(w, strings.NewReader(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := f.object("bucket/large.bin"); string(data) != content {
+		t.Errorf("object = %q, want %q", data, content)
+	}
+	if n := f.requestsMatching(http.MethodPut, "partNumber"); n != 3 {
+		t.Errorf("UploadPart requests = %d, want 3", n)
+	}
+	if result := w.Result(); result == nil || result.Size != int64(len(content)) {
+		t.Errorf("Result = %+v", result)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if last := events[len(events)-1]; last.Type != s3.TransferCompleted || last.ConsumedBytes != int64(len(content)) || last.TotalBytes != int64(len(content)) {
+		t.Errorf("last event = %+v", last)
+	}
+}
+
+func TestNewWriterAbortOnPartError(t *testing.T) {
+	f, srv := newFakeS3(t)
+	f.fail = func(r *http.Request) int {
+		if r.URL.Query().Get("partNumber") == "1" {
+			return http.StatusForbidden
+		}
+		return 0
+	}
+	c := newTestClient(srv)
+	w, err := c.NewWriter(context.Background(), "bucket", "fail.bin", &s3.MultipartOptions{PartSize: 4, ThreadNum: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 第一个分块在第二个分块写满时上传,失败后Write或Close返回该错误
+	_, err = w.Write([]byte("0123456789"))
+	if err == nil {
+		_, err = w.Write([]byte("abcd"))
+	}
+	closeErr := w.Close()
+	if closeErr == nil || !s3.IsAccessDenied(closeErr) {
+		t.Errorf("Close err = %v, want AccessDenied", closeErr)
+	}
+	if err != nil && !s3.IsAccessDenied(err) {
+		t.Errorf("Write err = %v, want AccessDenied", err)
+	}
+	if len(f.aborted) != 1 {
+		t.Errorf("aborted = %v, want the upload canceled", f.aborted)
+	}
+	if _, ok := f.object("bucket/fail.bin"); ok {
+		t.Error("object created after a failed part")
+	}
+	if w.Result() != nil {
+		t.Error("Result after failure != nil")
+	}
+}
+
+func TestNewWriterAbortOnCompleteError(t *testing.T) {
+	f, srv := newFakeS3(t)
+	f.fail = func(r *http.Request) int {
+		if r.Method == http.MethodPost && r.URL.Query().Has("uploadId") {
+			return http.StatusInternalServerError
+		}
+		return 0
+	}
+	c := newTestClient(srv)
+	w, err := c.NewWriter(context.Background(), "bucket", "fail.bin", &s3.MultipartOptions{PartSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write([]byte("0123456789")); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err == nil {
+		t.Fatal("Close succeeded")
+	}
+	if len(f.aborted) != 1 {
+		t.Errorf("aborted = %v, want the upload canceled", f.aborted)
+	}
+}
+
+func TestNewWriterAfterClose(t *testing.T) {
+	_, srv := newFakeS3(t)
+	c := newTestClient(srv)
+	w, err := c.NewWriter(context.Background(), "bucket", "closed.txt", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write([]byte("x")); err != io.ErrClosedPipe {
+		t.Errorf("Write after Close err = %v, want ErrClosedPipe", err)
+	}
+	if err = w.Close(); err != nil {
+		t.Errorf("second Close err = %v", err)
+	}
+	if err = w.CloseWithError(errors.New("abort")); err != nil {
+		t.Errorf("CloseWithError after Close err = %v", err)
+	}
+
+	// CloseWithError之后Write返回该错误,分块上传被取消
+	f, srv := newFakeS3(t)
+	c = newTestClient(srv)
+	w, err = c.NewWriter(context.Background(), "bucket", "canceled.bin", &s3.MultipartOptions{PartSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write([]byte("0123456789")); err != nil {
+		t.Fatal(err)
+	}
+	abortErr := errors.New("abort")
+	if err = w.CloseWithError(abortErr); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write([]byte("x")); err != abortErr {
+		t.Errorf("Write after CloseWithError err = %v, want %v", err, abortErr)
+	}
+	if err = w.Close(); err != abortErr {
+		t.Errorf("Close after CloseWithError err = %v, want %v", err, abortErr)
+	}
+	if len(f.aborted) != 1 {
+		t.Errorf("aborted = %v, want the upload canceled", f.aborted)
+	}
+	if _, ok := f.object("bucket/canceled.bin"); ok {
+		t.Error("object created after CloseWithError")
+	}
+}
+
+func TestNewWriterInvalidOptions(t *testing.T) {
+	_, srv := newFakeS3(t)
+	c := newTestClient(srv)
+	for _, opts := range []*s3.MultipartOptions{{PartSize: 1}, {PartSize: 2 << 20}, {ThreadNum: -1}} {
+		if _, err := c.NewWriter(context.Background(), "bucket", "x", opts); !errors.Is(err, s3.ErrInvalidOption) {
+			t.Errorf("NewWriter(%+v) err = %v, want ErrInvalidOption", opts, err)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package v4
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"encoding/xml"
 	"fmt"
@@ -13,11 +14,11 @@ import (
 	"strconv"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/shideqin/go-s3-sdk/pkg/internal"
 	"github.com/shideqin/go-s3-sdk/pkg/s3"
+	"github.com/shideqin/go-s3-sdk/pkg/signer"
 )
 
 // CopyObjectResult COPY结果
@@ -32,8 +33,28 @@ type ListObjectPrefixes = s3.ListObjectPrefixes
 // ListObjectContents 列表内容
 type ListObjectContents = s3.ListObjectContents
 
+// DeleteResult 批量删除结果
+type DeleteResult = s3.DeleteResult
+
 // UploadFile 上传文件根据路径
 func (c *Client) UploadFile(filePath, bucket, object string, options map[string]string) (map[string]interface{}, error) {
+	return c.UploadFileWithContext(context.Background(), filePath, bucket, object, options)
+}
+
+// UploadFileWithContext 上传文件根据路径,支持通过ctx取消和设置超时
+func (c *Client) UploadFileWithContext(ctx context.Context, filePath, bucket, object string, options map[string]string) (map[string]interface{}, error) {
+	result, err := c.UploadFileWithOptions(ctx, filePath, bucket, object, internal.PutOptions(options))
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// UploadFileWithOptions 上传文件根据路径,参数非法时返回s3.ErrInvalidOption
+func (c *Client) UploadFileWithOptions(ctx context.Context, filePath, bucket, object string, opts *s3.PutOptions) (*s3.PutResult, error) {
+	if opts == nil {
+		opts = &s3.PutOptions{}
+	}
 	fd, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf(" UploadFile Open localFile: %s Error: %v", filePath, err)
@@ -50,116 +71,168 @@ func (c *Client) UploadFile(filePath, bucket, object string, options map[string]
 	if strings.TrimSuffix(object, "/") == path.Dir(object) {
 		object = path.Dir(object) + "/" + path.Base(filePath)
 	}
-	return c.Put(fd, bodySize, bucket, object, map[string]string{"disposition": options["disposition"], "acl": options["acl"]})
+	return c.PutWithOptions(ctx, fd, bodySize, bucket, object, opts)
 }
 
 // Put 上传文件根据内容
 func (c *Client) Put(content io.Reader, bodySize int, bucket, object string, options map[string]string) (map[string]interface{}, error) {
-	nObject := url.QueryEscape(object)
-	host := fmt.Sprintf("%s.%s", bucket, c.host)
-	addr := fmt.Sprintf("http://%s/%s", host, nObject)
+	return c.PutWithContext(context.Background(), content, bodySize, bucket, object, options)
+}
+
+// PutWithContext 上传文件根据内容,支持通过ctx取消和设置超时
+func (c *Client) PutWithContext(ctx context.Context, content io.Reader, bodySize int, bucket, object string, options map[string]string) (map[string]interface{}, error) {
+	result, err := c.PutWithOptions(ctx, content, bodySize, bucket, object, internal.PutOptions(options))
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// PutWithOptions 上传文件根据内容,参数非法时返回s3.ErrInvalidOption
+func (c *Client) PutWithOptions(ctx context.Context, content io.Reader, bodySize int, bucket, object string, opts *s3.PutOptions) (result *s3.PutResult, err error) {
+	if opts == nil {
+		opts = &s3.PutOptions{}
+	}
+	start := time.Now()
+	progress := internal.NewProgress(opts.Progress)
+	defer func() {
+		progress.Close(err)
+	}()
+	progress.Start(int64(bodySize), 0)
+	nObject := signer.EscapePath(object)
+	host := c.endpoint.BucketHost(bucket)
+	addr := c.endpoint.URL(bucket, nObject)
 	method := "PUT"
 	date := time.Now().UTC().Format(c.iso8601FormatDateTime)
-	contentSha256 := hex.EncodeToString(hashSHA256Reader(content))
 	headers := map[string]string{
-		"host":                 host,
-		"x-amz-date":           date,
-		"x-amz-content-sha256": contentSha256,
+		"host":       host,
+		"x-amz-date": date,
 	}
-	if options["acl"] != "" {
-		headers["x-amz-acl"] = options["acl"]
+	if opts.ACL != "" {
+		headers["x-amz-acl"] = opts.ACL
+	}
+	if err = internal.SetChecksum(headers, c.checksum, content); err != nil {
+		return nil, fmt.Errorf(" Put Object: %s Error: %w", object, err)
+	}
+	auth, content, err := c.signBody(ctx, method, headers, c.endpoint.URI(bucket, nObject), nil, content, bodySize)
+	if err != nil {
+		return nil, fmt.Errorf(" Put Object: %s Error: %v", object, err)
 	}
-	headers["Authorization"] = c.sign(method, headers, "/"+nObject, "")
-	if options["disposition"] != "" {
-		headers["Content-Disposition"] = fmt.Sprintf(`attachment; filename="%s"`, options["disposition"])
+	headers["Authorization"] = auth
+	if opts.Disposition != "" {
+		headers["Content-Disposition"] = fmt.Sprintf(`attachment; filename="%s"`, opts.Disposition)
 	}
+	//aws-chunked编码后的请求体略大于bodySize,最多统计bodySize字节
 	body := &bytes.Buffer{}
-	header, err := internal.CURL(addr, method, headers, content, body, nil)
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, internal.ProgressReader(content, progress, int64(bodySize)), body)
 	if err != nil {
 		return nil, fmt.Errorf(" Put Object: %s Error: %v", object, err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" Put Object: %s StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", object, status, reqID, errorMsg.Code, errorMsg.Message)
-	}
-	return map[string]interface{}{
-		"X-Amz-Request-Id": reqID,
-		"StatusCode":       status,
-		"Location":         fmt.Sprintf("http://%s.%s/%s", bucket, c.host, object),
-		"Size":             bodySize,
-		"Bucket":           bucket,
-		"ETag":             header.Get("Etag"),
-		"Key":              object,
+		return nil, internal.NewResponseError("Put", bucket, object, header, errBody)
+	}
+	return &s3.PutResult{
+		Bucket:     bucket,
+		Key:        object,
+		ETag:       header.Get("Etag"),
+		Size:       int64(bodySize),
+		Location:   c.endpoint.URL(bucket, object),
+		StatusCode: http.StatusOK,
+		RequestID:  header.Get("X-Amz-Request-Id"),
+		Duration:   time.Since(start),
 	}, nil
 }
 
 // Copy 复制文件
 func (c *Client) Copy(bucket, object, source string, options map[string]string) (map[string]interface{}, error) {
+	return c.CopyWithContext(context.Background(), bucket, object, source, options)
+}
+
+// CopyWithContext 复制文件,支持通过ctx取消和设置超时
+func (c *Client) CopyWithContext(ctx context.Context, bucket, object, source string, options map[string]string) (map[string]interface{}, error) {
+	result, err := c.CopyWithOptions(ctx, bucket, object, source, internal.CopyOptions(options))
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// CopyWithOptions 复制文件,参数非法时返回s3.ErrInvalidOption
+func (c *Client) CopyWithOptions(ctx context.Context, bucket, object, source string, opts *s3.CopyOptions) (*s3.PutResult, error) {
+	if opts == nil {
+		opts = &s3.CopyOptions{}
+	}
+	start := time.Now()
 	//source head
 	tmpSourceInfo := strings.Split(source, "/")
 	sourceBucket := tmpSourceInfo[1]
 	sourceObject := strings.Join(tmpSourceInfo[2:], "/")
-	sourceHead, err := c.Head(sourceBucket, sourceObject)
+	sourceHead, err := c.HeadWithContext(ctx, sourceBucket, sourceObject)
 	if err != nil {
 		return nil, err
 	}
 	if object == "" {
 		object = path.Base(sourceObject)
 	}
-	nObject := url.QueryEscape(object)
-	host := fmt.Sprintf("%s.%s", bucket, c.host)
-	addr := fmt.Sprintf("http://%s/%s", host, nObject)
+	nObject := signer.EscapePath(object)
+	host := c.endpoint.BucketHost(bucket)
+	addr := c.endpoint.URL(bucket, nObject)
 	method := "PUT"
 	date := time.Now().UTC().Format(c.iso8601FormatDateTime)
 	headers := map[string]string{
 		"host":                 host,
 		"x-amz-date":           date,
 		"x-amz-content-sha256": c.emptyStringSHA256,
-		"x-amz-copy-source":    source,
+		"x-amz-copy-source":    signer.EscapePath(source),
 	}
-	if options["acl"] != "" {
-		headers["x-amz-acl"] = options["acl"]
+	if opts.ACL != "" {
+		headers["x-amz-acl"] = opts.ACL
 	}
-	headers["Authorization"] = c.sign(method, headers, "/"+nObject, "")
-	if options["disposition"] != "" {
-		headers["response-content-disposition"] = fmt.Sprintf(`attachment; filename="%s"`, options["disposition"])
+	auth, err := c.sign(ctx, method, headers, c.endpoint.URI(bucket, nObject), nil)
+	if err != nil {
+		return nil, fmt.Errorf(" Copy Object: %s Error: %v", object, err)
+	}
+	headers["Authorization"] = auth
+	if opts.Disposition != "" {
+		headers["response-content-disposition"] = fmt.Sprintf(`attachment; filename="%s"`, opts.Disposition)
 	}
 	body := &bytes.Buffer{}
-	header, cErr := internal.CURL(addr, method, headers, strings.NewReader(""), body, nil)
+	header, errBody, cErr := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), body)
 	if cErr != nil {
 		return nil, fmt.Errorf(" Copy Object: %s Error: %v", object, cErr)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" Copy Object: %s StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", object, status, reqID, errorMsg.Code, errorMsg.Message)
+		return nil, internal.NewResponseError("Copy", bucket, object, header, errBody)
 	}
 	var CopyObject = &CopyObjectResult{}
 	if err = xml.Unmarshal(body.Bytes(), CopyObject); err != nil {
 		return nil, fmt.Errorf(" Copy Object: %s Error: %v", object, err)
 	}
-	var contentLength, _ = strconv.Atoi(sourceHead.Get("Content-Length"))
-	return map[string]interface{}{
-		"X-Amz-Request-Id": reqID,
-		"StatusCode":       status,
-		"Location":         fmt.Sprintf("http://%s.%s/%s", bucket, c.host, object),
-		"Size":             contentLength,
-		"Bucket":           bucket,
-		"ETag":             CopyObject.ETag,
-		"Key":              object,
+	var contentLength, _ = strconv.ParseInt(sourceHead.Get("Content-Length"), 10, 64)
+	return &s3.PutResult{
+		Bucket:     bucket,
+		Key:        object,
+		ETag:       CopyObject.ETag,
+		Size:       contentLength,
+		Location:   c.endpoint.URL(bucket, object),
+		StatusCode: http.StatusOK,
+		RequestID:  header.Get("X-Amz-Request-Id"),
+		Duration:   time.Since(start),
 	}, nil
 }
 
 // Delete 删除文件
 func (c *Client) Delete(bucket, object string) (http.Header, error) {
-	nObject := url.QueryEscape(object)
-	host := fmt.Sprintf("%s.%s", bucket, c.host)
-	addr := fmt.Sprintf("http://%s/%s", host, nObject)
+	return c.DeleteWithContext(context.Background(), bucket, object)
+}
+
+// DeleteWithContext 删除文件,支持通过ctx取消和设置超时
+func (c *Client) DeleteWithContext(ctx context.Context, bucket, object string) (http.Header, error) {
+	nObject := signer.EscapePath(object)
+	host := c.endpoint.BucketHost(bucket)
+	addr := c.endpoint.URL(bucket, nObject)
 	method := "DELETE"
 	date := time.Now().UTC().Format(c.iso8601FormatDateTime)
 	headers := map[string]string{
@@ -167,19 +240,33 @@ func (c *Client) Delete(bucket, object string) (http.Header, error) {
 		"x-amz-date":           date,
 		"x-amz-content-sha256": c.emptyStringSHA256,
 	}
-	headers["Authorization"] = c.sign(method, headers, "/"+nObject, "")
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(""), nil, nil)
+	auth, err := c.sign(ctx, method, headers, c.endpoint.URI(bucket, nObject), nil)
 	if err != nil {
 		return nil, fmt.Errorf(" Delete Object: %s Error: %v", object, err)
 	}
+	headers["Authorization"] = auth
+	body := &bytes.Buffer{}
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), body)
+	if err != nil {
+		return nil, fmt.Errorf(" Delete Object: %s Error: %v", object, err)
+	}
+	var status = header.Get("StatusCode")
+	if status != "200" && status != "204" {
+		return nil, internal.NewResponseError("Delete", bucket, object, header, errBody)
+	}
 	return header, nil
 }
 
 // Head 查看文件信息
 func (c *Client) Head(bucket, object string) (http.Header, error) {
-	nObject := url.QueryEscape(object)
-	host := fmt.Sprintf("%s.%s", bucket, c.host)
-	addr := fmt.Sprintf("http://%s/%s", host, nObject)
+	return c.HeadWithContext(context.Background(), bucket, object)
+}
+
+// HeadWithContext 查看文件信息,支持通过ctx取消和设置超时
+func (c *Client) HeadWithContext(ctx context.Context, bucket, object string) (http.Header, error) {
+	nObject := signer.EscapePath(object)
+	host := c.endpoint.BucketHost(bucket)
+	addr := c.endpoint.URL(bucket, nObject)
 	method := "HEAD"
 	date := time.Now().UTC().Format(c.iso8601FormatDateTime)
 	headers := map[string]string{
@@ -187,22 +274,61 @@ func (c *Client) Head(bucket, object string) (http.Header, error) {
 		"x-amz-date":           date,
 		"x-amz-content-sha256": c.emptyStringSHA256,
 	}
-	headers["Authorization"] = c.sign(method, headers, "/"+nObject, "")
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(""), nil, nil)
+	if c.checksumValidation {
+		headers["x-amz-checksum-mode"] = "ENABLED"
+	}
+	auth, err := c.sign(ctx, method, headers, c.endpoint.URI(bucket, nObject), nil)
+	if err != nil {
+		return nil, fmt.Errorf(" Head Object: %s Error: %v", object, err)
+	}
+	headers["Authorization"] = auth
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), nil)
 	if err != nil {
 		return nil, fmt.Errorf(" Head Object: %s Error: %v", object, err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" {
-		return nil, fmt.Errorf(" Head Object: %s StatusCode: %s X-Amz-Request-Id: %s", object, status, reqID)
+		return nil, internal.NewResponseError("Head", bucket, object, header, errBody)
 	}
 	return header, nil
 }
 
 // Get 下载文件到本地
 func (c *Client) Get(bucket, object, localFile string, options map[string]string, percentChan chan int) (map[string]string, error) {
-	objectHead, headErr := c.Head(bucket, object)
+	return c.GetWithContext(context.Background(), bucket, object, localFile, options, percentChan)
+}
+
+// GetWithContext 下载文件到本地,支持通过ctx取消和设置超时
+func (c *Client) GetWithContext(ctx context.Context, bucket, object, localFile string, options map[string]string, percentChan chan int) (map[string]string, error) {
+	result, err := c.get(ctx, bucket, object, localFile, c.limits.GetOptions(options), percentChan)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// GetWithOptions 下载文件到本地,参数非法时返回s3.ErrInvalidOption
+func (c *Client) GetWithOptions(ctx context.Context, bucket, object, localFile string, opts *s3.GetOptions) (*s3.ObjectInfo, error) {
+	return c.get(ctx, bucket, object, localFile, opts, nil)
+}
+
+func (c *Client) get(ctx context.Context, bucket, object, localFile string, opts *s3.GetOptions, percentChan chan int) (info *s3.ObjectInfo, err error) {
+	if opts == nil {
+		opts = &s3.GetOptions{}
+	}
+	progress := internal.NewProgress(opts.Progress)
+	defer func() {
+		progress.Close(err)
+	}()
+	partSize, err := c.limits.PartSize(opts.PartSize, c.limits.PartMinSize)
+	if err != nil {
+		return nil, fmt.Errorf(" Get Error: %w", err)
+	}
+	threadNum, err := c.limits.ThreadNum(opts.ThreadNum)
+	if err != nil {
+		return nil, fmt.Errorf(" Get Error: %w", err)
+	}
+	objectHead, headErr := c.HeadWithContext(ctx, bucket, object)
 	if headErr != nil {
 		return nil, headErr
 	}
@@ -211,43 +337,52 @@ func (c *Client) Get(bucket, object, localFile string, options map[string]string
 	if strings.TrimSuffix(localFile, "/") == path.Dir(localFile) {
 		localFile = path.Dir(localFile) + "/" + path.Base(object)
 	}
-	var partSize = c.partMinSize
-	if options["part_size"] != "" {
-		n, _ := strconv.Atoi(options["part_size"])
-		if n <= c.partMaxSize && n >= c.partMinSize {
-			partSize = n
-		}
-	}
-	var threadNum = c.threadMaxNum
-	if options["thread_num"] != "" {
-		n, _ := strconv.Atoi(options["thread_num"])
-		if n <= c.threadMaxNum && n >= c.threadMinNum {
-			threadNum = n
-		}
-	}
 
 	//创建local文件
 	var localDir = path.Dir(localFile)
-	err := os.MkdirAll(localDir, 0666)
+	err = os.MkdirAll(localDir, 0666)
 	if err != nil {
 		return nil, fmt.Errorf(" Get MkdirAll LocalDir: %s Error: %v", localDir, err)
 	}
-	//file, oErr := os.OpenFile(localFile, os.O_CREATE|os.O_WRONLY, 0755)
-	file, oErr := os.Create(localFile)
+	//先下载到临时文件,全部分块完成后重命名;断点记录已写入的分块,对象ETag变化时重新下载
+	tmpFile := localFile + ".download"
+	checkpoint := internal.LoadDownloadCheckpoint(tmpFile + ".checkpoint")
+	flag := os.O_CREATE | os.O_WRONLY
+	if _, statErr := os.Stat(tmpFile); statErr == nil && checkpoint.Valid(bucket, object, objectHead.Get("Etag"), int64(objectSize)) {
+		partSize = checkpoint.PartSize
+	} else {
+		checkpoint = internal.NewDownloadCheckpoint(tmpFile+".checkpoint", bucket, object, objectHead.Get("Etag"), int64(objectSize), partSize)
+		flag |= os.O_TRUNC
+	}
+	file, oErr := os.OpenFile(tmpFile, flag, 0644)
 	if oErr != nil {
-		return nil, fmt.Errorf(" Get OpenFile localFile: %s Error: %v", localFile, oErr)
+		return nil, fmt.Errorf(" Get OpenFile localFile: %s Error: %v", tmpFile, oErr)
 	}
 	defer file.Close()
+	if err = checkpoint.Save(); err != nil {
+		return nil, fmt.Errorf(" Get Save checkpoint: %s Error: %v", tmpFile, err)
+	}
 	var total = (objectSize + partSize - 1) / partSize
+	progress.Start(int64(objectSize), 0)
 	var queueMaxSize = make(chan bool, threadNum)
 	defer close(queueMaxSize)
 	var partErr error
 	var partExit bool
 	var wg sync.WaitGroup
 	for partNum := 0; partNum < total; partNum++ {
+		if ctx.Err() != nil {
+			partErr = ctx.Err()
+			break
+		}
 		if partExit {
 			break
 		}
+		//跳过断点中已完成的分块
+		if checkpoint.Parts[partNum] {
+			progress.Transferred(int64(min(partSize, objectSize-partNum*partSize)))
+			internal.SendPercent(percentChan, total)
+			continue
+		}
 		wg.Add(1)
 		queueMaxSize <- true
 		go func(partNum int) {
@@ -274,21 +409,14 @@ func (c *Client) Get(bucket, object, localFile string, options map[string]string
 				tmpEnd = tmpStart + objectSize%partSize - 1
 			}
 			partRange := fmt.Sprintf("bytes=%d-%d", tmpStart, tmpEnd)
-			for i := 0; i < c.maxRetryNum; i++ {
-				_, sErr := tFile.Seek(0, io.SeekStart)
-				if sErr != nil {
-					partErr = sErr
-					continue
-				}
-				_, cErr := c.Cat(bucket, object, partRange, tFile)
-				if cErr != nil {
-					partErr = cErr
-					continue
-				}
-				partErr = nil
-				break
+			_, sErr := tFile.Seek(0, io.SeekStart)
+			if sErr != nil {
+				partErr = sErr
+				return
 			}
-			if partErr != nil {
+			_, cErr := c.CatWithContext(ctx, bucket, object, partRange, internal.ProgressWriter(tFile, progress))
+			if cErr != nil {
+				partErr = cErr
 				return
 			}
 			_, wErr := internal.FileIoCopyAt(tFile, file, tmpStart)
@@ -296,23 +424,50 @@ func (c *Client) Get(bucket, object, localFile string, options map[string]string
 				partErr = wErr
 				return
 			}
-			if percentChan != nil {
-				percentChan <- total
+			if cpErr := checkpoint.Done(partNum + 1); cpErr != nil {
+				partErr = fmt.Errorf(" Get Save checkpoint: %s Error: %v", tmpFile, cpErr)
+				return
 			}
+			internal.SendPercent(percentChan, total)
 		}(partNum)
 	}
 	wg.Wait()
 	if partErr != nil {
 		return nil, partErr
 	}
-	return map[string]string{"Object": object, "Localfile": localFile}, nil
+	if err = file.Sync(); err != nil {
+		return nil, fmt.Errorf(" Get Sync localFile: %s Error: %v", tmpFile, err)
+	}
+	if err = file.Close(); err != nil {
+		return nil, fmt.Errorf(" Get Close localFile: %s Error: %v", tmpFile, err)
+	}
+	//校验失败时删除临时文件及断点,重新执行时重新下载
+	if c.checksumValidation {
+		if err = internal.VerifyFileChecksum(tmpFile, objectHead, "Get", bucket, object); err != nil {
+			_ = os.Remove(tmpFile)
+			_ = checkpoint.Remove()
+			return nil, err
+		}
+	}
+	if err = os.Rename(tmpFile, localFile); err != nil {
+		return nil, fmt.Errorf(" Get Rename localFile: %s Error: %v", localFile, err)
+	}
+	_ = checkpoint.Remove()
+	info = internal.NewObjectInfo(bucket, object, objectHead)
+	info.LocalFile = localFile
+	return info, nil
 }
 
 // Cat 读取文件内容
 func (c *Client) Cat(bucket, object, partRange string, dsc io.Writer) (http.Header, error) {
-	nObject := url.QueryEscape(object)
-	host := fmt.Sprintf("%s.%s", bucket, c.host)
-	addr := fmt.Sprintf("http://%s/%s", host, nObject)
+	return c.CatWithContext(context.Background(), bucket, object, partRange, dsc)
+}
+
+// CatWithContext 读取文件内容,支持通过ctx取消和设置超时
+func (c *Client) CatWithContext(ctx context.Context, bucket, object, partRange string, dsc io.Writer) (http.Header, error) {
+	nObject := signer.EscapePath(object)
+	host := c.endpoint.BucketHost(bucket)
+	addr := c.endpoint.URL(bucket, nObject)
 	method := "GET"
 	date := time.Now().UTC().Format(c.iso8601FormatDateTime)
 	headers := map[string]string{
@@ -320,42 +475,93 @@ func (c *Client) Cat(bucket, object, partRange string, dsc io.Writer) (http.Head
 		"x-amz-date":           date,
 		"x-amz-content-sha256": c.emptyStringSHA256,
 	}
-	headers["Authorization"] = c.sign(method, headers, "/"+nObject, "")
+	//读取完整对象时校验内容
+	var checksumWriter *internal.ChecksumWriter
+	if c.checksumValidation && partRange == "" && dsc != nil {
+		headers["x-amz-checksum-mode"] = "ENABLED"
+		checksumWriter = internal.NewChecksumWriter(dsc, "Cat", bucket, object)
+		dsc = checksumWriter
+	}
+	auth, err := c.sign(ctx, method, headers, c.endpoint.URI(bucket, nObject), nil)
+	if err != nil {
+		return nil, fmt.Errorf(" Cat Object: %s Error: %v", object, err)
+	}
+	headers["Authorization"] = auth
 	//分片请求
 	if partRange != "" {
 		headers["Range"] = partRange
 	}
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(""), dsc, nil)
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), dsc)
 	if err != nil {
 		return nil, fmt.Errorf(" Cat Object: %s Error: %v", object, err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" && status != "206" {
-		return nil, fmt.Errorf(" Cat Object: %s StatusCode: %s X-Amz-Request-Id: %s", object, status, reqID)
+		return nil, internal.NewResponseError("Cat", bucket, object, header, errBody)
+	}
+	if checksumWriter != nil {
+		if err = checksumWriter.Verify(); err != nil {
+			return nil, err
+		}
 	}
 	return header, nil
 }
 
+// NewReader 按Range分块读取对象,返回可Seek及ReadAt的Reader,参数非法时返回s3.ErrInvalidOption
+// opts.PartSize为分块大小,opts.ThreadNum为Read时并发预读的分块数
+func (c *Client) NewReader(ctx context.Context, bucket, object string, opts *s3.GetOptions) (s3.ObjectReader, error) {
+	if opts == nil {
+		opts = &s3.GetOptions{}
+	}
+	partSize, err := c.limits.PartSize(opts.PartSize, c.limits.PartMinSize)
+	if err != nil {
+		return nil, fmt.Errorf(" NewReader Error: %w", err)
+	}
+	threadNum, err := c.limits.ThreadNum(opts.ThreadNum)
+	if err != nil {
+		return nil, fmt.Errorf(" NewReader Error: %w", err)
+	}
+	return internal.NewObjectReader(ctx, c, bucket, object, opts, partSize, threadNum)
+}
+
 // UploadFromDir 上传目录
 func (c *Client) UploadFromDir(localDir, bucket, prefix string, options map[string]string, percentChan chan int) (map[string]int, error) {
+	return c.UploadFromDirWithContext(context.Background(), localDir, bucket, prefix, options, percentChan)
+}
+
+// UploadFromDirWithContext 上传目录,支持通过ctx取消和设置超时
+func (c *Client) UploadFromDirWithContext(ctx context.Context, localDir, bucket, prefix string, options map[string]string, percentChan chan int) (map[string]int, error) {
+	result, err := c.uploadFromDir(ctx, localDir, bucket, prefix, c.limits.BulkOptions(options), percentChan)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// UploadFromDirWithOptions 上传目录,参数非法时返回s3.ErrInvalidOption
+func (c *Client) UploadFromDirWithOptions(ctx context.Context, localDir, bucket, prefix string, opts *s3.BulkOptions) (*s3.BulkResult, error) {
+	return c.uploadFromDir(ctx, localDir, bucket, prefix, opts, nil)
+}
+
+func (c *Client) uploadFromDir(ctx context.Context, localDir, bucket, prefix string, opts *s3.BulkOptions, percentChan chan int) (result *s3.BulkResult, err error) {
+	if opts == nil {
+		opts = &s3.BulkOptions{}
+	}
+	progress := internal.NewProgress(opts.Progress)
+	defer func() {
+		progress.Close(err)
+	}()
+	threadNum, err := c.limits.ThreadNum(opts.ThreadNum)
+	if err != nil {
+		return nil, fmt.Errorf(" UploadFromDir Error: %w", err)
+	}
 	if prefix != "" {
 		prefix = strings.TrimSuffix(prefix, "/") + "/"
 	}
-	suffix := ""
-	if options["suffix"] != "" {
-		suffix = options["suffix"]
-	}
+	suffix := strings.Join(opts.Suffix, ",")
 	localDir = strings.TrimSuffix(localDir, "/") + "/"
 	fileList := internal.WalkDir(localDir, suffix)
 	total := len(fileList)
-	var threadNum = c.threadMaxNum
-	if options["thread_num"] != "" {
-		n, _ := strconv.Atoi(options["thread_num"])
-		if n <= c.threadMaxNum && n >= c.threadMinNum {
-			threadNum = n
-		}
-	}
 	if total < threadNum {
 		threadNum = total
 	}
@@ -364,11 +570,22 @@ func (c *Client) UploadFromDir(localDir, bucket, prefix string, options map[stri
 	defer close(queueMaxSize)
 	var fileErr error
 	var fileExit bool
-	var tmpSize int64
-	var tmpSkip int64
-	var tmpFinish int64
+	counter := internal.NewBulkCounter()
+	if progress != nil {
+		var totalSize int64
+		for _, fileName := range fileList {
+			if stat, err := os.Stat(localDir + fileName); err == nil {
+				totalSize += stat.Size()
+			}
+		}
+		progress.Start(totalSize, total)
+	}
 	var wg sync.WaitGroup
 	for fileNum := 0; fileNum < total; fileNum++ {
+		if ctx.Err() != nil {
+			fileErr = ctx.Err()
+			break
+		}
 		if fileExit {
 			break
 		}
@@ -382,80 +599,93 @@ func (c *Client) UploadFromDir(localDir, bucket, prefix string, options map[stri
 				wg.Done()
 				<-queueMaxSize
 			}()
+			progress.FileStarted(fileName)
 			object := prefix + fileName
 			isSkipped := false
 			localFileStat, err := os.Stat(localDir + fileName)
 			if err != nil {
 				fileErr = fmt.Errorf(" UploadFromDir Stat localFile: %s%s Error: %v", localDir, fileName, err)
+				counter.Fail(fileName, fileErr)
+				progress.FileFailed(fileName, fileErr)
 				return
 			}
 			localFileSize := localFileStat.Size()
 			localFileTime := localFileStat.ModTime()
-			if options["replace"] != "true" {
-				var objectHead, _ = c.Head(bucket, object)
+			if !opts.Replace {
+				var objectHead, _ = c.HeadWithContext(ctx, bucket, object)
 				var objectHeadSize, _ = strconv.ParseInt(objectHead.Get("Content-Length"), 10, 64)
 				if localFileSize == objectHeadSize {
 					var objectTime, _ = time.Parse(c.dateTimeGMT, objectHead.Get("Last-Modified"))
 					if objectTime.Unix() >= localFileTime.Unix() {
 						isSkipped = true
-						atomic.AddInt64(&tmpSkip, 1)
+						counter.Skip(fileName)
+						progress.FileSkipped(fileName, localFileSize)
 					}
 				}
 			}
 			if !isSkipped {
-				for i := 0; i < c.maxRetryNum; i++ {
-					fd, oErr := os.Open(localDir + fileName)
-					if oErr != nil {
-						fileErr = fmt.Errorf(" UploadFromDir Open localFile: %s%s Error: %v", localDir, fileName, oErr)
-						continue
-					}
-					bodySize := int(localFileSize)
-					_, fileErr = c.Put(fd, bodySize, bucket, object, map[string]string{"disposition": fileName, "acl": options["acl"]})
-					fd.Close()
-					if fileErr != nil {
-						continue
-					}
-					break
+				fd, oErr := os.Open(localDir + fileName)
+				if oErr != nil {
+					fileErr = fmt.Errorf(" UploadFromDir Open localFile: %s%s Error: %v", localDir, fileName, oErr)
+					counter.Fail(fileName, fileErr)
+					progress.FileFailed(fileName, fileErr)
+					return
 				}
-				if fileErr != nil {
+				bodySize := int(localFileSize)
+				_, pErr := c.PutWithOptions(ctx, fd, bodySize, bucket, object, &s3.PutOptions{ACL: opts.ACL, Disposition: fileName, Progress: progress})
+				fd.Close()
+				if pErr != nil {
+					fileErr = pErr
+					counter.Fail(fileName, pErr)
+					progress.FileFailed(fileName, pErr)
 					return
 				}
-				atomic.AddInt64(&tmpSize, localFileSize)
-				atomic.AddInt64(&tmpFinish, 1)
-			}
-			if percentChan != nil {
-				percentChan <- total
+				counter.Finish(1, localFileSize)
+				progress.FileCompleted(fileName)
 			}
+			internal.SendPercent(percentChan, total)
 		}(fileList[fileNum])
 	}
 	wg.Wait()
-	if fileErr != nil {
-		return nil, fileErr
-	}
-	skip := int(atomic.LoadInt64(&tmpSkip))
-	finish := int(atomic.LoadInt64(&tmpFinish))
-	size := int(atomic.LoadInt64(&tmpSize))
-	return map[string]int{"Total": total, "Skip": skip, "Finish": finish, "Size": size}, nil
+	return counter.Result(total), fileErr
 }
 
 // ListObject 查看列表
 func (c *Client) ListObject(bucket string, options map[string]string) (*ListObjectResult, error) {
-	param := ""
-	if options["delimiter"] != "" {
-		param += "&delimiter=" + options["delimiter"]
+	return c.ListObjectWithContext(context.Background(), bucket, options)
+}
+
+// ListObjectWithContext 查看列表,支持通过ctx取消和设置超时
+func (c *Client) ListObjectWithContext(ctx context.Context, bucket string, options map[string]string) (*ListObjectResult, error) {
+	return c.ListObjectWithOptions(ctx, bucket, internal.ListOptions(options))
+}
+
+// ListObjectWithOptions 查看列表,参数非法时返回s3.ErrInvalidOption
+func (c *Client) ListObjectWithOptions(ctx context.Context, bucket string, opts *s3.ListOptions) (*ListObjectResult, error) {
+	if opts == nil {
+		opts = &s3.ListOptions{}
+	}
+	query := url.Values{}
+	if opts.Delimiter != "" {
+		query.Set("delimiter", opts.Delimiter)
 	}
-	if options["marker"] != "" {
-		param += "&marker=" + url.QueryEscape(options["marker"])
+	if opts.Marker != "" {
+		query.Set("marker", opts.Marker)
 	}
-	if options["max-keys"] != "" {
-		param += "&max-keys=" + options["max-keys"]
+	if opts.MaxKeys < 0 {
+		return nil, fmt.Errorf(" ListObject Bucket: %s Error: %w: MaxKeys %d must not be negative", bucket, s3.ErrInvalidOption, opts.MaxKeys)
 	}
-	if options["prefix"] != "" {
-		param += "&prefix=" + url.QueryEscape(options["prefix"])
+	if opts.MaxKeys > 0 {
+		query.Set("max-keys", strconv.Itoa(opts.MaxKeys))
+	}
+	if opts.Prefix != "" {
+		query.Set("prefix", opts.Prefix)
+	}
+	host := c.endpoint.BucketHost(bucket)
+	addr := c.endpoint.URL(bucket, "")
+	if len(query) > 0 {
+		addr += "?" + signer.CanonicalQuery(query)
 	}
-	object := strings.TrimPrefix(param, "&")
-	host := fmt.Sprintf("%s.%s", bucket, c.host)
-	addr := fmt.Sprintf("http://%s/?%s", host, object)
 	method := "GET"
 	date := time.Now().UTC().Format(c.iso8601FormatDateTime)
 	headers := map[string]string{
@@ -463,18 +693,19 @@ func (c *Client) ListObject(bucket string, options map[string]string) (*ListObje
 		"x-amz-date":           date,
 		"x-amz-content-sha256": c.emptyStringSHA256,
 	}
-	headers["Authorization"] = c.sign(method, headers, "/", object)
+	auth, err := c.sign(ctx, method, headers, c.endpoint.URI(bucket, ""), query)
+	if err != nil {
+		return nil, fmt.Errorf(" ListObject Bucket: %s Error: %v", bucket, err)
+	}
+	headers["Authorization"] = auth
 	body := &bytes.Buffer{}
-	header, err := internal.CURL(addr, method, headers, strings.NewReader(""), body, nil)
+	header, errBody, err := c.http.CURL(ctx, addr, method, headers, strings.NewReader(""), body)
 	if err != nil {
 		return nil, fmt.Errorf(" ListObject Bucket: %s Error: %v", bucket, err)
 	}
 	var status = header.Get("StatusCode")
-	var reqID = header.Get("X-Amz-Request-Id")
 	if status != "200" {
-		var errorMsg = &s3.Error{}
-		_ = xml.Unmarshal(body.Bytes(), errorMsg)
-		return nil, fmt.Errorf(" ListObject Bucket: %s StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", bucket, status, reqID, errorMsg.Code, errorMsg.Message)
+		return nil, internal.NewResponseError("ListObject", bucket, "", header, errBody)
 	}
 	var listObject = &ListObjectResult{}
 	if err = xml.Unmarshal(body.Bytes(), listObject); err != nil {
@@ -485,41 +716,67 @@ func (c *Client) ListObject(bucket string, options map[string]string) (*ListObje
 
 // CopyAllObject 复制目录
 func (c *Client) CopyAllObject(bucket, prefix, source string, options map[string]string, percentChan chan int) (map[string]int, error) {
+	return c.CopyAllObjectWithContext(context.Background(), bucket, prefix, source, options, percentChan)
+}
+
+// CopyAllObjectWithContext 复制目录,支持通过ctx取消和设置超时
+func (c *Client) CopyAllObjectWithContext(ctx context.Context, bucket, prefix, source string, options map[string]string, percentChan chan int) (map[string]int, error) {
+	result, err := c.copyAllObject(ctx, bucket, prefix, source, c.limits.BulkOptions(options), percentChan)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// CopyAllObjectWithOptions 复制目录,参数非法时返回s3.ErrInvalidOption
+func (c *Client) CopyAllObjectWithOptions(ctx context.Context, bucket, prefix, source string, opts *s3.BulkOptions) (*s3.BulkResult, error) {
+	return c.copyAllObject(ctx, bucket, prefix, source, opts, nil)
+}
+
+func (c *Client) copyAllObject(ctx context.Context, bucket, prefix, source string, opts *s3.BulkOptions, percentChan chan int) (result *s3.BulkResult, err error) {
+	if opts == nil {
+		opts = &s3.BulkOptions{}
+	}
+	progress := internal.NewProgress(opts.Progress)
+	defer func() {
+		progress.Close(err)
+	}()
+	threadNum, err := c.limits.ThreadNum(opts.ThreadNum)
+	if err != nil {
+		return nil, fmt.Errorf(" CopyAllObject Error: %w", err)
+	}
 	if prefix != "" {
 		prefix = strings.TrimSuffix(prefix, "/") + "/"
 	}
-	maxKeys := "1000"
-	if options["max-keys"] != "" {
-		maxKeys = options["max-keys"]
+	maxKeys := opts.MaxKeys
+	if maxKeys == 0 {
+		maxKeys = 1000
 	}
 	marker := ""
 	tmpSourceInfo := strings.Split(source, "/")
 	sourceBucket := tmpSourceInfo[1]
 	sourcePrefix := strings.Join(tmpSourceInfo[2:], "/")
 	total := 0
-	var threadNum = c.threadMaxNum
-	if options["thread_num"] != "" {
-		n, _ := strconv.Atoi(options["thread_num"])
-		if n <= c.threadMaxNum && n >= c.threadMinNum {
-			threadNum = n
-		}
-	}
 	var queueMaxSize = make(chan bool, threadNum)
 	defer close(queueMaxSize)
 	var copyExit bool
-	var tmpSize int64
-	var tmpSkip int64
-	var tmpFinish int64
+	counter := internal.NewBulkCounter()
+	progress.Start(0, 0)
 	var wg sync.WaitGroup
 LIST:
-	sourceList, err := c.ListObject(sourceBucket, map[string]string{"prefix": sourcePrefix, "marker": marker, "max-keys": maxKeys})
+	sourceList, err := c.ListObjectWithOptions(ctx, sourceBucket, &s3.ListOptions{Prefix: sourcePrefix, Marker: marker, MaxKeys: maxKeys})
 	if err != nil {
-		return nil, err
+		return counter.Result(total), err
 	}
 	sourceObjectNum := len(sourceList.Contents)
 	total += sourceObjectNum
+	progress.AddTotal(internal.ContentsSize(sourceList.Contents), sourceObjectNum)
 	var fileErr error
 	for fileNum := 0; fileNum < sourceObjectNum; fileNum++ {
+		if ctx.Err() != nil {
+			fileErr = ctx.Err()
+			break
+		}
 		if copyExit {
 			break
 		}
@@ -533,31 +790,27 @@ LIST:
 				wg.Done()
 				<-queueMaxSize
 			}()
+			progress.FileStarted(objectInfo.Key)
 			//根据后缀过滤处理
 			isSkipped := false
-			if options["suffix"] != "" {
-				suffixList := strings.Split(options["suffix"], ",")
-				for _, tmpSuffix := range suffixList {
-					if tmpSuffix != "" {
-						if strings.HasSuffix(strings.ToLower(objectInfo.Key), tmpSuffix) {
-							isSkipped = true
-							break
-						}
-					}
+			for _, tmpSuffix := range opts.Suffix {
+				if tmpSuffix != "" && strings.HasSuffix(strings.ToLower(objectInfo.Key), tmpSuffix) {
+					isSkipped = true
+					break
 				}
 			}
 			//支持自定义前缀
 			object := prefix
-			if options["full_path"] == "true" {
+			if opts.FullPath {
 				object += strings.Replace(objectInfo.Key, sourcePrefix, "", -1)
 			} else {
 				object += path.Base(objectInfo.Key)
 			}
-			var sourceHead, _ = c.Head(sourceBucket, objectInfo.Key)
+			var sourceHead, _ = c.HeadWithContext(ctx, sourceBucket, objectInfo.Key)
 			var sourceHeadSize, _ = strconv.ParseInt(sourceHead.Get("Content-Length"), 10, 64)
 			var disposition = internal.GetDisposition(sourceHead.Get("Content-Disposition"))
-			if options["replace"] != "true" {
-				var objectHead, _ = c.Head(bucket, object)
+			if !opts.Replace {
+				var objectHead, _ = c.HeadWithContext(ctx, bucket, object)
 				var objectHeadSize, _ = strconv.ParseInt(objectHead.Get("Content-Length"), 10, 64)
 				if sourceHeadSize == objectHeadSize {
 					var objectTime, _ = time.Parse(c.dateTimeGMT, objectHead.Get("Last-Modified"))
@@ -568,19 +821,21 @@ LIST:
 				}
 			}
 			if isSkipped {
-				atomic.AddInt64(&tmpSkip, 1)
+				counter.Skip(objectInfo.Key)
+				progress.FileSkipped(objectInfo.Key, int64(objectInfo.Size))
 			} else {
 				tmpSourceObject := "/" + sourceBucket + "/" + objectInfo.Key
-				_, fileErr = c.CopyLargeFile(bucket, object, tmpSourceObject, map[string]string{"thread_num": options["thread_num"], "part_size": options["part_size"], "disposition": disposition, "acl": options["acl"]}, nil, nil)
-				if fileErr != nil {
+				_, cErr := c.CopyLargeFileWithOptions(ctx, bucket, object, tmpSourceObject, &s3.MultipartOptions{ACL: opts.ACL, Disposition: disposition, PartSize: opts.PartSize, ThreadNum: opts.ThreadNum, Progress: progress})
+				if cErr != nil {
+					fileErr = cErr
+					counter.Fail(objectInfo.Key, cErr)
+					progress.FileFailed(objectInfo.Key, cErr)
 					return
 				}
-				atomic.AddInt64(&tmpSize, sourceHeadSize)
-				atomic.AddInt64(&tmpFinish, 1)
-			}
-			if percentChan != nil {
-				percentChan <- total
+				counter.Finish(1, sourceHeadSize)
+				progress.FileCompleted(objectInfo.Key)
 			}
+			internal.SendPercent(percentChan, total)
 		}(sourceList.Contents[fileNum])
 	}
 	wg.Wait()
@@ -588,55 +843,83 @@ LIST:
 		marker = sourceList.Contents[sourceObjectNum-1].Key
 		goto LIST
 	}
-	if fileErr != nil {
-		return nil, fileErr
-	}
-	skip := int(atomic.LoadInt64(&tmpSkip))
-	finish := int(atomic.LoadInt64(&tmpFinish))
-	size := int(atomic.LoadInt64(&tmpSize))
-	return map[string]int{"Total": total, "Skip": skip, "Finish": finish, "Size": size}, nil
+	return counter.Result(total), fileErr
 }
 
 // DeleteAllObject 删除目录
 func (c *Client) DeleteAllObject(bucket, prefix string, options map[string]string, percentChan chan int) (map[string]int, error) {
+	return c.DeleteAllObjectWithContext(context.Background(), bucket, prefix, options, percentChan)
+}
+
+// DeleteAllObjectWithContext 删除目录,支持通过ctx取消和设置超时
+func (c *Client) DeleteAllObjectWithContext(ctx context.Context, bucket, prefix string, options map[string]string, percentChan chan int) (map[string]int, error) {
+	result, err := c.deleteAllObject(ctx, bucket, prefix, c.limits.BulkOptions(options), percentChan)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// DeleteAllObjectWithOptions 删除目录,参数非法时返回s3.ErrInvalidOption
+func (c *Client) DeleteAllObjectWithOptions(ctx context.Context, bucket, prefix string, opts *s3.BulkOptions) (*s3.BulkResult, error) {
+	return c.deleteAllObject(ctx, bucket, prefix, opts, nil)
+}
+
+func (c *Client) deleteAllObject(ctx context.Context, bucket, prefix string, opts *s3.BulkOptions, percentChan chan int) (result *s3.BulkResult, err error) {
+	if opts == nil {
+		opts = &s3.BulkOptions{}
+	}
+	progress := internal.NewProgress(opts.Progress)
+	defer func() {
+		progress.Close(err)
+	}()
+	threadNum, err := c.limits.ThreadNum(opts.ThreadNum)
+	if err != nil {
+		return nil, fmt.Errorf(" DeleteAllObject Error: %w", err)
+	}
 	contents := make([]string, 0)
+	keys := make([][]string, 0)
 	counts := make([]int, 0)
-	maxKeys := "1000"
-	if options["max-keys"] != "" {
-		maxKeys = options["max-keys"]
+	sizes := make([]int64, 0)
+	objectSizes := make(map[string]int64)
+	maxKeys := opts.MaxKeys
+	if maxKeys == 0 {
+		maxKeys = 1000
 	}
 	marker := ""
 	total := 0
-	var tmpFinish int64
+	counter := internal.NewBulkCounter()
+	progress.Start(0, 0)
 LIST:
-	list, err := c.ListObject(bucket, map[string]string{"prefix": prefix, "marker": marker, "max-keys": maxKeys})
+	list, err := c.ListObjectWithOptions(ctx, bucket, &s3.ListOptions{Prefix: prefix, Marker: marker, MaxKeys: maxKeys})
 	if err != nil {
-		return nil, err
+		return counter.Result(total), err
 	}
 	total += len(list.Contents)
+	progress.AddTotal(0, len(list.Contents))
 	if total <= 0 {
-		return map[string]int{"total": 0, "finish": 0}, nil
+		return counter.Result(total), nil
 	}
 	content := "<Delete>"
 	content += "<Quiet>true</Quiet>"
+	var batchKeys []string
+	var batchSize int64
 	for _, v := range list.Contents {
 		content += "<Object><Key>" + v.Key + "</Key></Object>"
 		marker = v.Key
+		batchKeys = append(batchKeys, v.Key)
+		batchSize += int64(v.Size)
+		objectSizes[v.Key] = int64(v.Size)
 	}
 	content += "</Delete>"
 	contents = append(contents, content)
+	keys = append(keys, batchKeys)
 	counts = append(counts, len(list.Contents))
+	sizes = append(sizes, batchSize)
 	if list.IsTruncated == "true" {
 		goto LIST
 	}
 
-	var threadNum = c.threadMaxNum
-	if options["thread_num"] != "" {
-		n, _ := strconv.Atoi(options["thread_num"])
-		if n <= c.threadMaxNum && n >= c.threadMinNum {
-			threadNum = n
-		}
-	}
 	var contentCount = len(contents)
 	if contentCount < threadNum {
 		threadNum = contentCount
@@ -647,6 +930,10 @@ LIST:
 	var fileExit bool
 	var wg sync.WaitGroup
 	for fileNum := 0; fileNum < contentCount; fileNum++ {
+		if ctx.Err() != nil {
+			fileErr = ctx.Err()
+			break
+		}
 		if fileExit {
 			break
 		}
@@ -660,8 +947,9 @@ LIST:
 				wg.Done()
 				<-queueMaxSize
 			}()
-			host := fmt.Sprintf("%s.%s", bucket, c.host)
-			addr := fmt.Sprintf("http://%s/?delete", host)
+			host := c.endpoint.BucketHost(bucket)
+			query := url.Values{"delete": {""}}
+			addr := c.endpoint.URL(bucket, "") + "?" + signer.CanonicalQuery(query)
 			method := "POST"
 			date := time.Now().UTC().Format(c.iso8601FormatDateTime)
 			contentMd5 := internal.Base64Encode(internal.Md5Byte([]byte(content)))
@@ -672,37 +960,91 @@ LIST:
 				"x-amz-date":           date,
 				"x-amz-content-sha256": contentSha256,
 			}
-			headers["Authorization"] = c.sign(method, headers, "/", "delete=")
+			auth, sErr := c.sign(ctx, method, headers, c.endpoint.URI(bucket, ""), query)
+			if sErr != nil {
+				fileErr = fmt.Errorf(" DeleteAllObject Prefix: %s Error: %v", prefix, sErr)
+				counter.Fail(prefix, fileErr)
+				for _, key := range keys[fileNum] {
+					progress.FileFailed(key, fileErr)
+				}
+				return
+			}
+			headers["Authorization"] = auth
 			body := &bytes.Buffer{}
-			header, cErr := internal.CURL(addr, method, headers, strings.NewReader(content), body, nil)
+			header, errBody, cErr := c.http.CURL(ctx, addr, method, headers, strings.NewReader(content), body)
 			if cErr != nil {
 				fileErr = fmt.Errorf(" DeleteAllObject Prefix: %s Error: %v", prefix, cErr)
+				counter.Fail(prefix, fileErr)
+				for _, key := range keys[fileNum] {
+					progress.FileFailed(key, fileErr)
+				}
 				return
 			}
 			var status = header.Get("StatusCode")
-			var reqID = header.Get("X-Amz-Request-Id")
 			if status != "200" {
-				var errorMsg = &s3.Error{}
-				_ = xml.Unmarshal(body.Bytes(), errorMsg)
-				fileErr = fmt.Errorf(" DeleteAllObject Prefix: %s StatusCode: %s X-Amz-Request-Id: %s Code: %s Message: %s", prefix, status, reqID, errorMsg.Code, errorMsg.Message)
+				fileErr = internal.NewResponseError("DeleteAllObject", bucket, prefix, header, errBody)
+				counter.Fail(prefix, fileErr)
+				for _, key := range keys[fileNum] {
+					progress.FileFailed(key, fileErr)
+				}
 				return
 			}
-			atomic.AddInt64(&tmpFinish, int64(counts[fileNum]))
-			if percentChan != nil {
-				percentChan <- total
+			//Quiet模式只返回删除失败的对象
+			var deleteResult = &DeleteResult{}
+			_ = xml.Unmarshal(body.Bytes(), deleteResult)
+			finishSize := sizes[fileNum]
+			failed := make(map[string]bool, len(deleteResult.Error))
+			for _, v := range deleteResult.Error {
+				finishSize -= objectSizes[v.Key]
+				failed[v.Key] = true
+				deleteErr := &s3.ResponseError{Operation: "DeleteAllObject", Bucket: bucket, Key: v.Key, StatusCode: http.StatusOK, Code: v.Code, Message: v.Message, RequestID: header.Get("X-Amz-Request-Id")}
+				counter.Fail(v.Key, deleteErr)
+				progress.FileFailed(v.Key, deleteErr)
+			}
+			counter.Finish(counts[fileNum]-len(deleteResult.Error), finishSize)
+			for _, key := range keys[fileNum] {
+				if !failed[key] {
+					progress.FileCompleted(key)
+				}
 			}
+			internal.SendPercent(percentChan, total)
 		}(fileNum, contents[fileNum])
 	}
 	wg.Wait()
-	if fileErr != nil {
-		return nil, fileErr
-	}
-	finish := int(atomic.LoadInt64(&tmpFinish))
-	return map[string]int{"Total": total, "Finish": finish}, nil
+	return counter.Result(total), fileErr
 }
 
 // MoveAllObject 移动目录
 func (c *Client) MoveAllObject(bucket, prefix, source string, options map[string]string, percentChan chan int) (map[string]int, error) {
+	return c.MoveAllObjectWithContext(context.Background(), bucket, prefix, source, options, percentChan)
+}
+
+// MoveAllObjectWithContext 移动目录,支持通过ctx取消和设置超时
+func (c *Client) MoveAllObjectWithContext(ctx context.Context, bucket, prefix, source string, options map[string]string, percentChan chan int) (map[string]int, error) {
+	result, err := c.moveAllObject(ctx, bucket, prefix, source, c.limits.BulkOptions(options), percentChan)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// MoveAllObjectWithOptions 移动目录,参数非法时返回s3.ErrInvalidOption
+func (c *Client) MoveAllObjectWithOptions(ctx context.Context, bucket, prefix, source string, opts *s3.BulkOptions) (*s3.BulkResult, error) {
+	return c.moveAllObject(ctx, bucket, prefix, source, opts, nil)
+}
+
+func (c *Client) moveAllObject(ctx context.Context, bucket, prefix, source string, opts *s3.BulkOptions, percentChan chan int) (result *s3.BulkResult, err error) {
+	if opts == nil {
+		opts = &s3.BulkOptions{}
+	}
+	progress := internal.NewProgress(opts.Progress)
+	defer func() {
+		progress.Close(err)
+	}()
+	threadNum, err := c.limits.ThreadNum(opts.ThreadNum)
+	if err != nil {
+		return nil, fmt.Errorf(" MoveAllObject Error: %w", err)
+	}
 	if prefix != "" {
 		prefix = strings.TrimSuffix(prefix, "/") + "/"
 	}
@@ -712,35 +1054,32 @@ func (c *Client) MoveAllObject(bucket, prefix, source string, options map[string
 	if bucket == sourceBucket && prefix == sourcePrefix {
 		return nil, fmt.Errorf("move soure-prefix and target-prefix same not allowed")
 	}
-	maxKeys := "1000"
-	if options["max-keys"] != "" {
-		maxKeys = options["max-keys"]
+	maxKeys := opts.MaxKeys
+	if maxKeys == 0 {
+		maxKeys = 1000
 	}
 	marker := ""
 	total := 0
-	var threadNum = c.threadMaxNum
-	if options["thread_num"] != "" {
-		n, _ := strconv.Atoi(options["thread_num"])
-		if n <= c.threadMaxNum && n >= c.threadMinNum {
-			threadNum = n
-		}
-	}
 	var queueMaxSize = make(chan bool, threadNum)
 	defer close(queueMaxSize)
 	var copyExit bool
-	var tmpSize int64
-	var tmpSkip int64
-	var tmpFinish int64
+	counter := internal.NewBulkCounter()
+	progress.Start(0, 0)
 	var wg sync.WaitGroup
 LIST:
-	sourceList, err := c.ListObject(sourceBucket, map[string]string{"prefix": sourcePrefix, "marker": marker, "max-keys": maxKeys})
+	sourceList, err := c.ListObjectWithOptions(ctx, sourceBucket, &s3.ListOptions{Prefix: sourcePrefix, Marker: marker, MaxKeys: maxKeys})
 	if err != nil {
-		return nil, err
+		return counter.Result(total), err
 	}
 	sourceObjectNum := len(sourceList.Contents)
 	total += sourceObjectNum
+	progress.AddTotal(internal.ContentsSize(sourceList.Contents), sourceObjectNum)
 	var fileErr error
 	for fileNum := 0; fileNum < sourceObjectNum; fileNum++ {
+		if ctx.Err() != nil {
+			fileErr = ctx.Err()
+			break
+		}
 		if copyExit {
 			break
 		}
@@ -754,31 +1093,27 @@ LIST:
 				wg.Done()
 				<-queueMaxSize
 			}()
+			progress.FileStarted(objectInfo.Key)
 			//根据后缀过滤处理
 			isSkipped := false
-			if options["suffix"] != "" {
-				suffixList := strings.Split(options["suffix"], ",")
-				for _, tmpSuffix := range suffixList {
-					if tmpSuffix != "" {
-						if strings.HasSuffix(strings.ToLower(objectInfo.Key), tmpSuffix) {
-							isSkipped = true
-							break
-						}
-					}
+			for _, tmpSuffix := range opts.Suffix {
+				if tmpSuffix != "" && strings.HasSuffix(strings.ToLower(objectInfo.Key), tmpSuffix) {
+					isSkipped = true
+					break
 				}
 			}
 			//支持自定义前缀
 			object := prefix
-			if options["full_path"] == "true" {
+			if opts.FullPath {
 				object += strings.Replace(objectInfo.Key, sourcePrefix, "", -1)
 			} else {
 				object += path.Base(objectInfo.Key)
 			}
-			var sourceHead, _ = c.Head(sourceBucket, objectInfo.Key)
+			var sourceHead, _ = c.HeadWithContext(ctx, sourceBucket, objectInfo.Key)
 			var sourceHeadSize, _ = strconv.ParseInt(sourceHead.Get("Content-Length"), 10, 64)
 			var disposition = internal.GetDisposition(sourceHead.Get("Content-Disposition"))
-			if options["replace"] != "true" {
-				var objectHead, _ = c.Head(bucket, object)
+			if !opts.Replace {
+				var objectHead, _ = c.HeadWithContext(ctx, bucket, object)
 				var objectHeadSize, _ = strconv.ParseInt(objectHead.Get("Content-Length"), 10, 64)
 				if sourceHeadSize == objectHeadSize {
 					var objectTime, _ = time.Parse(c.dateTimeGMT, objectHead.Get("Last-Modified"))
@@ -789,30 +1124,29 @@ LIST:
 				}
 			}
 			if isSkipped {
-				atomic.AddInt64(&tmpSkip, 1)
+				counter.Skip(objectInfo.Key)
+				progress.FileSkipped(objectInfo.Key, int64(objectInfo.Size))
 			} else {
 				tmpSourceObject := "/" + sourceBucket + "/" + objectInfo.Key
-				_, fileErr = c.CopyLargeFile(bucket, object, tmpSourceObject, map[string]string{"thread_num": options["thread_num"], "part_size": options["part_size"], "disposition": disposition, "acl": options["acl"]}, nil, nil)
-				if fileErr != nil {
+				_, cErr := c.CopyLargeFileWithOptions(ctx, bucket, object, tmpSourceObject, &s3.MultipartOptions{ACL: opts.ACL, Disposition: disposition, PartSize: opts.PartSize, ThreadNum: opts.ThreadNum, Progress: progress})
+				if cErr != nil {
+					fileErr = cErr
+					counter.Fail(objectInfo.Key, cErr)
+					progress.FileFailed(objectInfo.Key, cErr)
 					return
 				}
 				//删除源文件
-				for i := 0; i < c.maxRetryNum; i++ {
-					_, fileErr = c.Delete(sourceBucket, objectInfo.Key)
-					if fileErr != nil {
-						continue
-					}
-					break
-				}
-				if fileErr != nil {
+				_, dErr := c.DeleteWithContext(ctx, sourceBucket, objectInfo.Key)
+				if dErr != nil {
+					fileErr = dErr
+					counter.Fail(objectInfo.Key, dErr)
+					progress.FileFailed(objectInfo.Key, dErr)
 					return
 				}
-				atomic.AddInt64(&tmpSize, sourceHeadSize)
-				atomic.AddInt64(&tmpFinish, 1)
-			}
-			if percentChan != nil {
-				percentChan <- total
+				counter.Finish(1, sourceHeadSize)
+				progress.FileCompleted(objectInfo.Key)
 			}
+			internal.SendPercent(percentChan, total)
 		}(sourceList.Contents[fileNum])
 	}
 	wg.Wait()
@@ -820,45 +1154,66 @@ LIST:
 		marker = sourceList.Contents[sourceObjectNum-1].Key
 		goto LIST
 	}
-	if fileErr != nil {
-		return nil, fileErr
-	}
-	skip := int(atomic.LoadInt64(&tmpSkip))
-	finish := int(atomic.LoadInt64(&tmpFinish))
-	size := int(atomic.LoadInt64(&tmpSize))
-	return map[string]int{"Total": total, "Skip": skip, "Finish": finish, "Size": size}, nil
+	return counter.Result(total), fileErr
 }
 
 // DownloadAllObject 下载目录
 func (c *Client) DownloadAllObject(bucket, prefix, localDir string, options map[string]string, percentChan chan int) (map[string]int, error) {
-	maxKeys := "1000"
-	if options["max-keys"] != "" {
-		maxKeys = options["max-keys"]
+	return c.DownloadAllObjectWithContext(context.Background(), bucket, prefix, localDir, options, percentChan)
+}
+
+// DownloadAllObjectWithContext 下载目录,支持通过ctx取消和设置超时
+func (c *Client) DownloadAllObjectWithContext(ctx context.Context, bucket, prefix, localDir string, options map[string]string, percentChan chan int) (map[string]int, error) {
+	result, err := c.downloadAllObject(ctx, bucket, prefix, localDir, c.limits.BulkOptions(options), percentChan)
+	if err != nil {
+		return nil, err
+	}
+	return result.Map(), nil
+}
+
+// DownloadAllObjectWithOptions 下载目录,参数非法时返回s3.ErrInvalidOption
+func (c *Client) DownloadAllObjectWithOptions(ctx context.Context, bucket, prefix, localDir string, opts *s3.BulkOptions) (*s3.BulkResult, error) {
+	return c.downloadAllObject(ctx, bucket, prefix, localDir, opts, nil)
+}
+
+func (c *Client) downloadAllObject(ctx context.Context, bucket, prefix, localDir string, opts *s3.BulkOptions, percentChan chan int) (result *s3.BulkResult, err error) {
+	if opts == nil {
+		opts = &s3.BulkOptions{}
+	}
+	progress := internal.NewProgress(opts.Progress)
+	defer func() {
+		progress.Close(err)
+	}()
+	threadNum, err := c.limits.ThreadNum(opts.ThreadNum)
+	if err != nil {
+		return nil, fmt.Errorf(" DownloadAllObject Error: %w", err)
+	}
+	maxKeys := opts.MaxKeys
+	if maxKeys == 0 {
+		maxKeys = 1000
 	}
 	marker := ""
 	total := 0
-	var threadNum = c.threadMaxNum
-	if options["thread_num"] != "" {
-		n, _ := strconv.Atoi(options["thread_num"])
-		if n <= c.threadMaxNum && n >= c.threadMinNum {
-			threadNum = n
-		}
-	}
 	var queueMaxSize = make(chan bool, threadNum)
 	defer close(queueMaxSize)
-	var tmpSkip int64
-	var tmpFinish int64
+	counter := internal.NewBulkCounter()
+	progress.Start(0, 0)
 	var wg sync.WaitGroup
 LIST:
-	list, err := c.ListObject(bucket, map[string]string{"prefix": prefix, "marker": marker, "max-keys": maxKeys})
+	list, err := c.ListObjectWithOptions(ctx, bucket, &s3.ListOptions{Prefix: prefix, Marker: marker, MaxKeys: maxKeys})
 	if err != nil {
-		return nil, err
+		return counter.Result(total), err
 	}
 	objectNum := len(list.Contents)
 	total += objectNum
+	progress.AddTotal(internal.ContentsSize(list.Contents), objectNum)
 	var fileErr error
 	var fileExit bool
 	for fileNum := 0; fileNum < objectNum; fileNum++ {
+		if ctx.Err() != nil {
+			fileErr = ctx.Err()
+			break
+		}
 		if fileExit {
 			break
 		}
@@ -872,43 +1227,34 @@ LIST:
 				wg.Done()
 				<-queueMaxSize
 			}()
+			progress.FileStarted(objectInfo.Key)
 			localFile := strings.TrimSuffix(localDir, "/") + "/" + objectInfo.Key
 			isSkipped := false
-			if options["replace"] != "true" {
-				var objectHead, _ = c.Head(bucket, objectInfo.Key)
+			if !opts.Replace {
+				var objectHead, _ = c.HeadWithContext(ctx, bucket, objectInfo.Key)
 				var objectHeadSize, _ = strconv.ParseInt(objectHead.Get("Content-Length"), 10, 64)
 				fileStat, sErr := os.Stat(localFile)
 				if sErr == nil && objectHeadSize == fileStat.Size() {
 					var objectTime, _ = time.Parse(c.dateTimeGMT, objectHead.Get("Last-Modified"))
 					if objectTime.Unix() >= fileStat.ModTime().Unix() {
 						isSkipped = true
-						atomic.AddInt64(&tmpSkip, 1)
+						counter.Skip(objectInfo.Key)
+						progress.FileSkipped(objectInfo.Key, int64(objectInfo.Size))
 					}
 				}
 			}
 			if !isSkipped {
-				var getPercent = make(chan int)
-				defer close(getPercent)
-				go func() {
-					for {
-						_, ok := <-getPercent
-						if !ok {
-							break
-						}
-					}
-				}()
-				_, fileErr = c.Get(bucket, objectInfo.Key, localFile, map[string]string{
-					"thread_num": options["thread_num"],
-					"part_size":  options["part_size"],
-				}, getPercent)
-				if fileErr != nil {
+				info, gErr := c.GetWithOptions(ctx, bucket, objectInfo.Key, localFile, &s3.GetOptions{PartSize: opts.PartSize, ThreadNum: opts.ThreadNum, Progress: progress})
+				if gErr != nil {
+					fileErr = gErr
+					counter.Fail(objectInfo.Key, gErr)
+					progress.FileFailed(objectInfo.Key, gErr)
 					return
 				}
-				atomic.AddInt64(&tmpFinish, 1)
-			}
-			if percentChan != nil {
-				percentChan <- total
+				counter.Finish(1, info.Size)
+				progress.FileCompleted(objectInfo.Key)
 			}
+			internal.SendPercent(percentChan, total)
 		}(list.Contents[fileNum])
 	}
 	wg.Wait()
@@ -916,10 +1262,5 @@ LIST:
 		marker = list.Contents[objectNum-1].Key
 		goto LIST
 	}
-	if fileErr != nil {
-		return nil, fileErr
-	}
-	skip := int(atomic.LoadInt64(&tmpSkip))
-	finish := int(atomic.LoadInt64(&tmpFinish))
-	return map[string]int{"Total": total, "Skip": skip, "Finish": finish}, nil
+	return counter.Result(total), fileErr
 }
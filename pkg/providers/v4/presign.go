@@ -0,0 +1,105 @@
+package v4
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shideqin/go-s3-sdk/pkg/internal"
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+	"github.com/shideqin/go-s3-sdk/pkg/signer"
+)
+
+// Presign 生成预签名URL,method为空时使用GET,expires为0时有效期15分钟
+func (c *Client) Presign(method, bucket, object string, expires time.Duration) (string, error) {
+	return c.PresignWithOptions(context.Background(), bucket, object, &s3.PresignOptions{Method: method, Expires: expires})
+}
+
+// PresignWithOptions 生成预签名URL,支持response-*等查询参数及参与签名的请求头,参数非法时返回s3.ErrInvalidOption
+func (c *Client) PresignWithOptions(ctx context.Context, bucket, object string, opts *s3.PresignOptions) (string, error) {
+	if opts == nil {
+		opts = &s3.PresignOptions{}
+	}
+	expires := opts.Expires
+	if expires == 0 {
+		expires = 15 * time.Minute
+	}
+	//v4预签名最长7天
+	if expires < time.Second || expires > 7*24*time.Hour {
+		return "", fmt.Errorf(" Presign Object: %s Error: %w: Expires %s out of range [1s, 168h]", object, s3.ErrInvalidOption, expires)
+	}
+	creds, err := c.credentials.Retrieve(ctx)
+	if err != nil {
+		return "", fmt.Errorf(" Presign Object: %s Error: %v", object, err)
+	}
+	return c.presign(creds, bucket, object, opts, expires, time.Now().UTC()), nil
+}
+
+func (c *Client) presign(creds s3.Credentials, bucket, object string, opts *s3.PresignOptions, expires time.Duration, dt time.Time) string {
+	method := strings.ToUpper(opts.Method)
+	if method == "" {
+		method = "GET"
+	}
+	nObject := signer.EscapePath(object)
+	headers := map[string]string{
+		"host": c.endpoint.BucketHost(bucket),
+	}
+	for k, v := range opts.Headers {
+		headers[strings.ToLower(k)] = strings.TrimSpace(v)
+	}
+	query := url.Values{}
+	for k, v := range opts.Query {
+		query.Set(k, v)
+	}
+	query.Set("X-Amz-Algorithm", c.authHeaderPrefix)
+	query.Set("X-Amz-Credential", creds.AccessKeyID+"/"+c.buildCredentialString(dt))
+	query.Set("X-Amz-Date", dt.Format(c.iso8601FormatDateTime))
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expires/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", c.canonicalSignHeaders(headers))
+	if creds.SessionToken != "" {
+		query.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	canonQuery := signer.CanonicalQuery(query)
+	signature := c.buildSignature(creds.SecretAccessKey, method, headers, c.endpoint.URI(bucket, nObject), canonQuery, c.unsignedPayload, dt)
+	return c.endpoint.URL(bucket, nObject) + "?" + canonQuery + "&X-Amz-Signature=" + signature
+}
+
+// PresignPost 生成浏览器表单上传的地址及字段
+func (c *Client) PresignPost(policy *s3.PostPolicy) (*s3.PostForm, error) {
+	return c.PresignPostWithContext(context.Background(), policy)
+}
+
+// PresignPostWithContext 生成浏览器表单上传的地址及字段,参数非法时返回s3.ErrInvalidOption
+func (c *Client) PresignPostWithContext(ctx context.Context, policy *s3.PostPolicy) (*s3.PostForm, error) {
+	if policy == nil {
+		policy = &s3.PostPolicy{}
+	}
+	creds, err := c.credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(" PresignPost Bucket: %s Error: %v", policy.Bucket, err)
+	}
+	dt := time.Now().UTC()
+	signFields := map[string]string{
+		"x-amz-algorithm":  c.authHeaderPrefix,
+		"x-amz-credential": creds.AccessKeyID + "/" + c.buildCredentialString(dt),
+		"x-amz-date":       dt.Format(c.iso8601FormatDateTime),
+	}
+	if creds.SessionToken != "" {
+		signFields["x-amz-security-token"] = creds.SessionToken
+	}
+	encoded, fields, err := internal.PostPolicy(policy, dt, signFields)
+	if err != nil {
+		return nil, fmt.Errorf(" PresignPost Bucket: %s Error: %w", policy.Bucket, err)
+	}
+	fields["x-amz-signature"] = c.postSignature(creds.SecretAccessKey, dt, encoded)
+	return &s3.PostForm{URL: c.endpoint.URL(policy.Bucket, ""), Fields: fields}, nil
+}
+
+// postSignature 使用签名密钥对base64编码的policy签名
+func (c *Client) postSignature(secret string, dt time.Time, policy string) string {
+	return hex.EncodeToString(hmacSHA256(c.deriveSigningKey(secret, dt), []byte(policy)))
+}
@@ -0,0 +1,335 @@
+package v4
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
+var testModTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// fakeS3 基于httptest的path-style服务,支持对象的读写、列举及分块上传
+type fakeS3 struct {
+	// fail 返回非0时以该状态码响应请求
+	fail func(r *http.Request) int
+
+	mu       sync.Mutex
+	objects  map[string][]byte
+	uploads  map[string]map[int][]byte
+	nextID   int
+	aborted  []string
+	requests []*http.Request
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	f := &fakeS3{objects: map[string][]byte{}, uploads: map[string]map[int][]byte{}}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+// newTestClient 使用path-style访问srv的客户端,分块大小下限为4字节,不重试
+func newTestClient(srv *httptest.Server, opts ...s3.Option) *Client {
+	opts = append([]s3.Option{s3.WithPathStyle(), s3.WithPartSize(4, 1<<20), s3.WithMaxRetryNum(0)}, opts...)
+	return New(srv.URL, "id", "secret", opts...)
+}
+
+func etag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, "<Error><Code>%s</Code></Error>", code)
+}
+
+func writeXML(w http.ResponseWriter, v interface{}) {
+	body, _ := xml.Marshal(v)
+	_, _ = w.Write(body)
+}
+
+// readBody 读取请求体,aws-chunked编码时解码
+func readBody(r *http.Request) ([]byte, error) {
+	if !strings.Contains(r.Header.Get("Content-Encoding"), "aws-chunked") {
+		return io.ReadAll(r.Body)
+	}
+	var data []byte
+	br := bufio.NewReader(r.Body)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return data, nil
+		}
+		chunk := make([]byte, size+2)
+		if _, err = io.ReadFull(br, chunk); err != nil {
+			return nil, err
+		}
+		data = append(data, chunk[:size]...)
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests = append(f.requests, r.Clone(r.Context()))
+	f.mu.Unlock()
+	if f.fail != nil {
+		if status := f.fail(r); status != 0 {
+			_, _ = io.Copy(io.Discard, r.Body)
+			writeError(w, status, http.StatusText(status))
+			return
+		}
+	}
+	body, err := readBody(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody")
+		return
+	}
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	query := r.URL.Query()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case key == "" && query.Has("delete") && r.Method == http.MethodPost:
+		f.deleteObjects(w, bucket, body)
+	case key == "" && r.Method == http.MethodGet:
+		f.list(w, bucket, query.Get("prefix"), query.Get("delimiter"), query.Get("marker"), query.Get("max-keys"))
+	case query.Has("uploads") && r.Method == http.MethodPost:
+		f.nextID++
+		uploadID := fmt.Sprintf("upload-%d", f.nextID)
+		f.uploads[uploadID] = map[int][]byte{}
+		writeXML(w, &s3.InitUploadResult{Bucket: bucket, Key: key, UploadID: uploadID})
+	case query.Has("uploadId"):
+		f.multipart(w, r, bucket, key, body)
+	case r.Method == http.MethodPut:
+		if source := r.Header.Get("X-Amz-Copy-Source"); source != "" {
+			data, ok := f.objects[strings.TrimPrefix(source, "/")]
+			if !ok {
+				writeError(w, http.StatusNotFound, "NoSuchKey")
+				return
+			}
+			body = data
+		}
+		f.objects[bucket+"/"+key] = body
+		w.Header().Set("ETag", etag(body))
+	case r.Method == http.MethodDelete:
+		delete(f.objects, bucket+"/"+key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		f.get(w, r, bucket+"/"+key)
+	}
+}
+
+func (f *fakeS3) get(w http.ResponseWriter, r *http.Request, name string) {
+	data, ok := f.objects[name]
+	if !ok {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeError(w, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+	w.Header().Set("ETag", etag(data))
+	w.Header().Set("Last-Modified", testModTime.Format(http.TimeFormat))
+	status := http.StatusOK
+	if rng := r.Header.Get("Range"); rng != "" {
+		var start, end int
+		if _, err := fmt.Sscanf(rng, "bytes=%d-%d", &start, &end); err != nil || start >= len(data) {
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		end = min(end, len(data)-1)
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(data)))
+		data, status = data[start:end+1], http.StatusPartialContent
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(data)
+	}
+}
+
+func (f *fakeS3) multipart(w http.ResponseWriter, r *http.Request, bucket, key string, body []byte) {
+	uploadID := r.URL.Query().Get("uploadId")
+	parts, ok := f.uploads[uploadID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		partNumber, _ := strconv.Atoi(r.URL.Query().Get("partNumber"))
+		parts[partNumber] = body
+		w.Header().Set("ETag", etag(body))
+	case http.MethodGet:
+		numbers := make([]int, 0, len(parts))
+		for n := range parts {
+			numbers = append(numbers, n)
+		}
+		sort.Ints(numbers)
+		type part struct {
+			PartNumber   int    `xml:"PartNumber"`
+			LastModified string `xml:"LastModified"`
+			ETag         string `xml:"ETag"`
+			Size         int64  `xml:"Size"`
+		}
+		result := struct {
+			XMLName     xml.Name `xml:"ListPartsResult"`
+			Bucket      string   `xml:"Bucket"`
+			Key         string   `xml:"Key"`
+			UploadID    string   `xml:"UploadId"`
+			IsTruncated string   `xml:"IsTruncated"`
+			Part        []part   `xml:"Part"`
+		}{Bucket: bucket, Key: key, UploadID: uploadID, IsTruncated: "false"}
+		for _, n := range numbers {
+			result.Part = append(result.Part, part{PartNumber: n, LastModified: testModTime.Format(time.RFC3339), ETag: etag(parts[n]), Size: int64(len(parts[n]))})
+		}
+		writeXML(w, result)
+	case http.MethodPost:
+		var complete struct {
+			Part []struct {
+				PartNumber int    `xml:"PartNumber"`
+				ETag       string `xml:"ETag"`
+			} `xml:"Part"`
+		}
+		if err := xml.Unmarshal(body, &complete); err != nil {
+			writeError(w, http.StatusBadRequest, "MalformedXML")
+			return
+		}
+		var data bytes.Buffer
+		for _, part := range complete.Part {
+			if etag(parts[part.PartNumber]) != part.ETag {
+				writeError(w, http.StatusBadRequest, "InvalidPart")
+				return
+			}
+			data.Write(parts[part.PartNumber])
+		}
+		delete(f.uploads, uploadID)
+		f.objects[bucket+"/"+key] = data.Bytes()
+		writeXML(w, &s3.CompleteUploadResult{Bucket: bucket, Key: key, ETag: etag(data.Bytes())})
+	case http.MethodDelete:
+		delete(f.uploads, uploadID)
+		f.aborted = append(f.aborted, uploadID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, bucket, prefix, delimiter, marker, maxKeys string) {
+	limit := 1000
+	if n, err := strconv.Atoi(maxKeys); err == nil && n > 0 {
+		limit = n
+	}
+	keys := make([]string, 0, len(f.objects))
+	for name := range f.objects {
+		if k, ok := strings.CutPrefix(name, bucket+"/"); ok && strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	result := &s3.ListObjectResult{Name: bucket, Prefix: prefix, Marker: marker, Delimiter: delimiter, IsTruncated: "false"}
+	var count int
+	var last string
+	for _, k := range keys {
+		entry, isPrefix := k, false
+		if i := strings.Index(k[len(prefix):], delimiter); delimiter != "" && i >= 0 {
+			entry, isPrefix = k[:len(prefix)+i+len(delimiter)], true
+		}
+		if entry <= marker || entry == last {
+			continue
+		}
+		if count == limit {
+			result.IsTruncated = "true"
+			break
+		}
+		last = entry
+		if isPrefix {
+			result.CommonPrefixes = append(result.CommonPrefixes, s3.ListObjectPrefixes{Prefix: entry})
+		} else {
+			data := f.objects[bucket+"/"+k]
+			result.Contents = append(result.Contents, s3.ListObjectContents{Key: k, Size: len(data), ETag: etag(data), LastModified: testModTime.Format(time.RFC3339)})
+		}
+		count++
+	}
+	writeXML(w, result)
+}
+
+// deleteObjects 批量删除,Quiet模式只返回删除失败的对象,对象名包含locked时删除失败
+func (f *fakeS3) deleteObjects(w http.ResponseWriter, bucket string, body []byte) {
+	var request struct {
+		Object []struct {
+			Key string `xml:"Key"`
+		} `xml:"Object"`
+	}
+	if err := xml.Unmarshal(body, &request); err != nil {
+		writeError(w, http.StatusBadRequest, "MalformedXML")
+		return
+	}
+	type deleteError struct {
+		Key     string `xml:"Key"`
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	result := struct {
+		XMLName xml.Name      `xml:"DeleteResult"`
+		Error   []deleteError `xml:"Error"`
+	}{}
+	for _, object := range request.Object {
+		if strings.Contains(object.Key, "locked") {
+			result.Error = append(result.Error, deleteError{Key: object.Key, Code: "AccessDenied", Message: "Access Denied"})
+			continue
+		}
+		delete(f.objects, bucket+"/"+object.Key)
+	}
+	writeXML(w, result)
+}
+
+// object 返回对象内容,不存在时ok为false
+func (f *fakeS3) object(name string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, ok := f.objects[name]
+	return data, ok
+}
+
+// requestsMatching 返回method相同且查询参数包含param的请求数
+func (f *fakeS3) requestsMatching(method, param string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var n int
+	for _, r := range f.requests {
+		if r.Method == method && (param == "" && r.URL.RawQuery == "" || param != "" && r.URL.Query().Has(param)) {
+			n++
+		}
+	}
+	return n
+}
+
+// lastRequest 返回最后收到的请求
+func (f *fakeS3) lastRequest() *http.Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[len(f.requests)-1]
+}
@@ -0,0 +1,287 @@
+package s3
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"time"
+)
+
+// Config 客户端配置
+type Config struct {
+	// HTTPClient 自定义http客户端,设置后忽略Transport及连接相关配置
+	HTTPClient *http.Client
+	// Transport 自定义http传输层,设置后忽略连接相关配置
+	Transport http.RoundTripper
+	// TLSConfig https连接配置
+	TLSConfig *tls.Config
+
+	ConnectTimeout      time.Duration
+	HeaderTimeout       time.Duration
+	ReadTimeout         time.Duration
+	KeepAlive           time.Duration
+	MaxIdleConnsPerHost int
+
+	// Region v4签名使用的region,为空时根据服务地址识别
+	Region string
+	// Service v4签名使用的服务名,默认s3
+	Service string
+	// PathStyle 使用 host/bucket/key 形式访问,适用于MinIO、Ceph等
+	PathStyle bool
+
+	// PayloadSigning v4请求体的签名方式,默认PayloadSigned
+	PayloadSigning PayloadSigning
+
+	// Checksum 上传时发送的附加校验和算法,为空时不发送
+	Checksum ChecksumAlgorithm
+	// ChecksumValidation Cat、Get校验完整对象的校验和或单次上传对象的MD5 ETag
+	ChecksumValidation bool
+
+	// Credentials 凭证提供者,每次请求签名时获取凭证,为空时使用New传入的固定密钥
+	Credentials CredentialsProvider
+
+	// RetryPolicy 重试策略,为空时使用MaxRetryNum创建默认策略
+	RetryPolicy RetryPolicy
+	// RetryBudget 客户端所有请求(包括并发的分块)共享的重试额度,每次重试占用1,请求结束(无论成功失败)时归还,0表示不限制
+	RetryBudget int
+
+	PartMinSize  int
+	PartMaxSize  int
+	MaxRetryNum  int
+	ThreadMinNum int
+	ThreadMaxNum int
+}
+
+// PayloadSigning v4请求体的签名方式
+type PayloadSigning int
+
+const (
+	// PayloadSigned 计算请求体的sha256,请求体不可Seek时按PayloadStreaming处理
+	PayloadSigned PayloadSigning = iota
+	// PayloadUnsigned 使用UNSIGNED-PAYLOAD,请求体只读取一次,由TLS保证完整性,http下按PayloadSigned处理
+	PayloadUnsigned
+	// PayloadStreaming 使用STREAMING-AWS4-HMAC-SHA256-PAYLOAD分块签名,请求体只读取一次
+	PayloadStreaming
+)
+
+// ChecksumAlgorithm 附加校验和算法
+type ChecksumAlgorithm string
+
+const (
+	ChecksumCRC32     ChecksumAlgorithm = "CRC32"
+	ChecksumCRC32C    ChecksumAlgorithm = "CRC32C"
+	ChecksumSHA1      ChecksumAlgorithm = "SHA1"
+	ChecksumSHA256    ChecksumAlgorithm = "SHA256"
+	ChecksumCRC64NVME ChecksumAlgorithm = "CRC64NVME"
+)
+
+// Option 客户端配置项
+type Option func(*Config)
+
+// NewConfig 根据默认值和配置项生成配置
+func NewConfig(opts ...Option) *Config {
+	cfg := &Config{
+		ConnectTimeout:      60 * time.Second,
+		HeaderTimeout:       600 * time.Second,
+		ReadTimeout:         600 * time.Second,
+		KeepAlive:           60 * time.Second,
+		MaxIdleConnsPerHost: 200,
+
+		Service: "s3",
+
+		RetryBudget: 100,
+
+		PartMinSize:  1 * 1024 * 1024,
+		PartMaxSize:  10 * 1024 * 1024,
+		MaxRetryNum:  10,
+		ThreadMinNum: 1,
+		ThreadMaxNum: 10,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(cfg)
+		}
+	}
+	return cfg
+}
+
+// WithHTTPClient 使用自定义http客户端
+func WithHTTPClient(client *http.Client) Option {
+	return func(cfg *Config) {
+		cfg.HTTPClient = client
+	}
+}
+
+// WithTransport 使用自定义http传输层
+func WithTransport(transport http.RoundTripper) Option {
+	return func(cfg *Config) {
+		cfg.Transport = transport
+	}
+}
+
+// WithConnectTimeout 设置连接超时
+func WithConnectTimeout(timeout time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.ConnectTimeout = timeout
+	}
+}
+
+// WithHeaderTimeout 设置等待响应头超时
+func WithHeaderTimeout(timeout time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.HeaderTimeout = timeout
+	}
+}
+
+// WithReadTimeout 设置单个请求的整体超时
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.ReadTimeout = timeout
+	}
+}
+
+// WithKeepAlive 设置连接保活时间
+func WithKeepAlive(keepAlive time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.KeepAlive = keepAlive
+	}
+}
+
+// WithMaxIdleConnsPerHost 设置每个host的最大空闲连接数
+func WithMaxIdleConnsPerHost(n int) Option {
+	return func(cfg *Config) {
+		cfg.MaxIdleConnsPerHost = n
+	}
+}
+
+// WithRegion 设置v4签名使用的region
+func WithRegion(region string) Option {
+	return func(cfg *Config) {
+		cfg.Region = region
+	}
+}
+
+// WithService 设置v4签名使用的服务名
+func WithService(service string) Option {
+	return func(cfg *Config) {
+		cfg.Service = service
+	}
+}
+
+// WithPathStyle 使用 host/bucket/key 形式访问
+func WithPathStyle() Option {
+	return func(cfg *Config) {
+		cfg.PathStyle = true
+	}
+}
+
+// WithPayloadSigning 设置v4请求体的签名方式
+func WithPayloadSigning(mode PayloadSigning) Option {
+	return func(cfg *Config) {
+		cfg.PayloadSigning = mode
+	}
+}
+
+// WithChecksum 设置上传时发送的附加校验和算法
+// Put、UploadPart发送x-amz-checksum-*,请求体需可Seek,否则返回ErrInvalidOption;InitUpload声明算法,CompleteUpload附带各分块的校验和
+func WithChecksum(algorithm ChecksumAlgorithm) Option {
+	return func(cfg *Config) {
+		cfg.Checksum = algorithm
+	}
+}
+
+// WithChecksumValidation 下载时校验对象内容
+// Cat读取完整对象、Get下载完成后校验服务端返回的完整对象校验和,没有时校验单次上传对象的MD5 ETag
+func WithChecksumValidation() Option {
+	return func(cfg *Config) {
+		cfg.ChecksumValidation = true
+	}
+}
+
+// WithCredentials 设置凭证提供者,设置后忽略New传入的密钥
+func WithCredentials(provider CredentialsProvider) Option {
+	return func(cfg *Config) {
+		cfg.Credentials = provider
+	}
+}
+
+// WithPartSize 设置分块大小范围
+func WithPartSize(minSize, maxSize int) Option {
+	return func(cfg *Config) {
+		cfg.PartMinSize = minSize
+		cfg.PartMaxSize = maxSize
+	}
+}
+
+// WithMaxRetryNum 设置默认重试策略的最大重试次数,0表示不重试
+func WithMaxRetryNum(n int) Option {
+	return func(cfg *Config) {
+		cfg.MaxRetryNum = n
+	}
+}
+
+// WithRetryPolicy 设置重试策略
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(cfg *Config) {
+		cfg.RetryPolicy = policy
+	}
+}
+
+// WithRetryBudget 设置共享的重试额度,0表示不限制
+func WithRetryBudget(n int) Option {
+	return func(cfg *Config) {
+		cfg.RetryBudget = n
+	}
+}
+
+// WithThreadNum 设置并发数范围
+func WithThreadNum(minNum, maxNum int) Option {
+	return func(cfg *Config) {
+		cfg.ThreadMinNum = minNum
+		cfg.ThreadMaxNum = maxNum
+	}
+}
+
+// WithTLSConfig 设置https连接配置
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(cfg *Config) {
+		cfg.TLSConfig = tlsConfig
+	}
+}
+
+// WithCACertificates 追加PEM格式的自定义CA证书
+func WithCACertificates(pemCerts []byte) Option {
+	return func(cfg *Config) {
+		tlsConfig := cfg.tls()
+		if tlsConfig.RootCAs == nil {
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			tlsConfig.RootCAs = pool
+		}
+		tlsConfig.RootCAs.AppendCertsFromPEM(pemCerts)
+	}
+}
+
+// WithClientCertificate 设置双向认证的客户端证书
+func WithClientCertificate(cert tls.Certificate) Option {
+	return func(cfg *Config) {
+		tlsConfig := cfg.tls()
+		tlsConfig.Certificates = append(tlsConfig.Certificates, cert)
+	}
+}
+
+// WithInsecureSkipVerify 跳过服务端证书校验,仅用于测试环境
+func WithInsecureSkipVerify() Option {
+	return func(cfg *Config) {
+		cfg.tls().InsecureSkipVerify = true
+	}
+}
+
+func (cfg *Config) tls() *tls.Config {
+	if cfg.TLSConfig == nil {
+		cfg.TLSConfig = &tls.Config{}
+	}
+	return cfg.TLSConfig
+}
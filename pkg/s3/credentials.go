@@ -0,0 +1,305 @@
+package s3
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoCredentials 凭证提供者没有找到可用的凭证
+var ErrNoCredentials = errors.New("s3: no valid credentials")
+
+// Credentials 访问凭证
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken STS临时凭证的token
+	SessionToken string
+	// Expires 过期时间,零值表示不过期
+	Expires time.Time
+}
+
+// ExpiresWithin 凭证是否在window时间内过期
+func (c Credentials) ExpiresWithin(window time.Duration) bool {
+	return !c.Expires.IsZero() && !time.Now().Add(window).Before(c.Expires)
+}
+
+// CredentialsProvider 凭证提供者,每次请求签名时调用
+type CredentialsProvider interface {
+	Retrieve(ctx context.Context) (Credentials, error)
+}
+
+// CredentialsFunc 函数形式的CredentialsProvider
+type CredentialsFunc func(ctx context.Context) (Credentials, error)
+
+// Retrieve 获取凭证
+func (f CredentialsFunc) Retrieve(ctx context.Context) (Credentials, error) {
+	return f(ctx)
+}
+
+// StaticCredentials 固定的凭证
+func StaticCredentials(accessKeyID, secretAccessKey, sessionToken string) CredentialsProvider {
+	creds := Credentials{AccessKeyID: accessKeyID, SecretAccessKey: secretAccessKey, SessionToken: sessionToken}
+	return CredentialsFunc(func(context.Context) (Credentials, error) {
+		return creds, nil
+	})
+}
+
+// EnvCredentials 从环境变量读取凭证
+// AWS_ACCESS_KEY_ID(或AWS_ACCESS_KEY)、AWS_SECRET_ACCESS_KEY(或AWS_SECRET_KEY)、AWS_SESSION_TOKEN
+func EnvCredentials() CredentialsProvider {
+	return CredentialsFunc(func(context.Context) (Credentials, error) {
+		creds := Credentials{
+			AccessKeyID:     firstEnv("AWS_ACCESS_KEY_ID", "AWS_ACCESS_KEY"),
+			SecretAccessKey: firstEnv("AWS_SECRET_ACCESS_KEY", "AWS_SECRET_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+		if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+			return Credentials{}, fmt.Errorf("%w: AWS_ACCESS_KEY_ID or AWS_SECRET_ACCESS_KEY not set", ErrNoCredentials)
+		}
+		return creds, nil
+	})
+}
+
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// ProfileCredentials 从 ~/.aws/credentials 及 ~/.aws/config 读取profile的凭证
+// 支持 aws_access_key_id、aws_secret_access_key、aws_session_token 及 credential_process,
+// credentials文件中的配置优先
+type ProfileCredentials struct {
+	// Profile 为空时使用环境变量AWS_PROFILE,仍为空时使用default
+	Profile string
+	// CredentialsFile 为空时使用环境变量AWS_SHARED_CREDENTIALS_FILE,仍为空时使用 ~/.aws/credentials
+	CredentialsFile string
+	// ConfigFile 为空时使用环境变量AWS_CONFIG_FILE,仍为空时使用 ~/.aws/config
+	ConfigFile string
+}
+
+// NewProfileCredentials 创建读取profile凭证的提供者,凭证缓存至过期前5分钟,不过期的凭证在Invalidate后重新读取
+func NewProfileCredentials(profile string) *CachedCredentials {
+	return NewCachedCredentials(&ProfileCredentials{Profile: profile}, defaultExpiryWindow)
+}
+
+// Retrieve 读取profile的凭证,每次调用都会重新读取文件
+func (p *ProfileCredentials) Retrieve(ctx context.Context) (Credentials, error) {
+	profile := p.Profile
+	if profile == "" {
+		profile = firstEnv("AWS_PROFILE")
+	}
+	if profile == "" {
+		profile = "default"
+	}
+	credentialsFile, err := awsFile(p.CredentialsFile, "AWS_SHARED_CREDENTIALS_FILE", "credentials")
+	if err != nil {
+		return Credentials{}, err
+	}
+	configFile, err := awsFile(p.ConfigFile, "AWS_CONFIG_FILE", "config")
+	if err != nil {
+		return Credentials{}, err
+	}
+	values := map[string]string{}
+	//config文件中default以外的profile写作 [profile name]
+	configSection := "profile " + profile
+	if profile == "default" {
+		configSection = "default"
+	}
+	for _, file := range []struct{ name, section string }{{configFile, configSection}, {credentialsFile, profile}} {
+		section, err := readIniSection(file.name, file.section)
+		if err != nil {
+			return Credentials{}, err
+		}
+		for k, v := range section {
+			values[k] = v
+		}
+	}
+	if values["aws_access_key_id"] != "" && values["aws_secret_access_key"] != "" {
+		return Credentials{
+			AccessKeyID:     values["aws_access_key_id"],
+			SecretAccessKey: values["aws_secret_access_key"],
+			SessionToken:    values["aws_session_token"],
+		}, nil
+	}
+	if command := values["credential_process"]; command != "" {
+		return processCredentials(command).Retrieve(ctx)
+	}
+	return Credentials{}, fmt.Errorf("%w: profile %s not found", ErrNoCredentials, profile)
+}
+
+func awsFile(name, env, base string) (string, error) {
+	if name != "" {
+		return name, nil
+	}
+	if name = os.Getenv(env); name != "" {
+		return name, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrNoCredentials, err)
+	}
+	return filepath.Join(home, ".aws", base), nil
+}
+
+// readIniSection 读取ini文件中指定section的配置,文件不存在时返回空
+func readIniSection(name, section string) (map[string]string, error) {
+	values := map[string]string{}
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var current string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			current = strings.Join(strings.Fields(line[1:len(line)-1]), " ")
+			continue
+		}
+		if current != section {
+			continue
+		}
+		if k, v, ok := strings.Cut(line, "="); ok {
+			values[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+		}
+	}
+	return values, scanner.Err()
+}
+
+// ProcessCredentials 执行外部命令获取凭证,命令输出credential_process格式的JSON:
+// {"Version": 1, "AccessKeyId": "", "SecretAccessKey": "", "SessionToken": "", "Expiration": "RFC3339时间"}
+// 凭证缓存至Expiration前5分钟,未返回Expiration时只执行一次命令
+func ProcessCredentials(command string) *CachedCredentials {
+	return NewCachedCredentials(processCredentials(command), defaultExpiryWindow)
+}
+
+// processCredentials 每次调用都执行命令
+func processCredentials(command string) CredentialsProvider {
+	return CredentialsFunc(func(ctx context.Context) (Credentials, error) {
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.CommandContext(ctx, "cmd.exe", "/C", command)
+		} else {
+			cmd = exec.CommandContext(ctx, "sh", "-c", command)
+		}
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return Credentials{}, fmt.Errorf(" credential_process Error: %v %s", err, strings.TrimSpace(stderr.String()))
+		}
+		var output struct {
+			Version         int
+			AccessKeyID     string `json:"AccessKeyId"`
+			SecretAccessKey string
+			SessionToken    string
+			Expiration      *time.Time
+		}
+		if err = json.Unmarshal(out, &output); err != nil {
+			return Credentials{}, fmt.Errorf(" credential_process Error: %v", err)
+		}
+		if output.Version != 1 {
+			return Credentials{}, fmt.Errorf(" credential_process Error: unsupported version %d", output.Version)
+		}
+		if output.AccessKeyID == "" || output.SecretAccessKey == "" {
+			return Credentials{}, fmt.Errorf("%w: credential_process returned empty keys", ErrNoCredentials)
+		}
+		creds := Credentials{
+			AccessKeyID:     output.AccessKeyID,
+			SecretAccessKey: output.SecretAccessKey,
+			SessionToken:    output.SessionToken,
+		}
+		if output.Expiration != nil {
+			creds.Expires = *output.Expiration
+		}
+		return creds, nil
+	})
+}
+
+// ChainCredentials 依次尝试providers,返回第一个成功获取的凭证
+func ChainCredentials(providers ...CredentialsProvider) CredentialsProvider {
+	return CredentialsFunc(func(ctx context.Context) (Credentials, error) {
+		var errs []error
+		for _, provider := range providers {
+			creds, err := provider.Retrieve(ctx)
+			if err == nil {
+				return creds, nil
+			}
+			errs = append(errs, err)
+		}
+		if len(errs) == 0 {
+			return Credentials{}, ErrNoCredentials
+		}
+		return Credentials{}, errors.Join(errs...)
+	})
+}
+
+// 缓存凭证提前刷新的默认时间
+const defaultExpiryWindow = 5 * time.Minute
+
+// CachedCredentials 缓存凭证,在过期前ExpiryWindow时间内重新获取
+type CachedCredentials struct {
+	Provider CredentialsProvider
+	// ExpiryWindow 提前刷新的时间
+	ExpiryWindow time.Duration
+
+	mu    sync.Mutex
+	creds *Credentials
+}
+
+// NewCachedCredentials 创建缓存凭证
+func NewCachedCredentials(provider CredentialsProvider, expiryWindow time.Duration) *CachedCredentials {
+	return &CachedCredentials{Provider: provider, ExpiryWindow: expiryWindow}
+}
+
+// Retrieve 返回缓存的凭证,即将过期时重新获取
+// 重新获取失败但缓存的凭证尚未过期时,继续使用缓存的凭证
+func (c *CachedCredentials) Retrieve(ctx context.Context) (Credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.creds != nil && !c.creds.ExpiresWithin(c.ExpiryWindow) {
+		return *c.creds, nil
+	}
+	creds, err := c.Provider.Retrieve(ctx)
+	if err != nil {
+		if c.creds != nil && !c.creds.ExpiresWithin(0) {
+			return *c.creds, nil
+		}
+		return Credentials{}, err
+	}
+	c.creds = &creds
+	return creds, nil
+}
+
+// Invalidate 清除缓存,下次调用时重新获取
+func (c *CachedCredentials) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.creds = nil
+}
+
+// DefaultCredentials 依次从环境变量、profile获取凭证,并在过期前5分钟刷新
+func DefaultCredentials() *CachedCredentials {
+	return NewCachedCredentials(ChainCredentials(EnvCredentials(), &ProfileCredentials{}), defaultExpiryWindow)
+}
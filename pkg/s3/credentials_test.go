@@ -0,0 +1,116 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProcessCredentialsCached(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "count")
+	expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	command := `echo x >> ` + counter + `; echo '{"Version": 1, "AccessKeyId": "AKID", "SecretAccessKey": "SECRET", "SessionToken": "TOKEN", "Expiration": "` + expires + `"}'`
+	provider := ProcessCredentials(command)
+	for i := 0; i < 3; i++ {
+		creds, err := provider.Retrieve(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if creds.AccessKeyID != "AKID" || creds.SecretAccessKey != "SECRET" || creds.SessionToken != "TOKEN" || creds.Expires.IsZero() {
+			t.Fatalf("creds = %+v", creds)
+		}
+	}
+	data, _ := os.ReadFile(counter)
+	if n := strings.Count(string(data), "x"); n != 1 {
+		t.Errorf("command executed %d times, want 1", n)
+	}
+	provider.Invalidate()
+	if _, err := provider.Retrieve(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(counter)
+	if n := strings.Count(string(data), "x"); n != 2 {
+		t.Errorf("command executed %d times after Invalidate, want 2", n)
+	}
+}
+
+func TestProcessCredentialsError(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	tests := []struct {
+		command string
+		noCreds bool
+	}{
+		{`exit 1`, false},
+		{`echo not-json`, false},
+		{`echo '{"Version": 2, "AccessKeyId": "AKID", "SecretAccessKey": "SECRET"}'`, false},
+		{`echo '{"Version": 1}'`, true},
+	}
+	for _, tt := range tests {
+		_, err := ProcessCredentials(tt.command).Retrieve(context.Background())
+		if err == nil {
+			t.Errorf("%s: err = nil", tt.command)
+			continue
+		}
+		if errors.Is(err, ErrNoCredentials) != tt.noCreds {
+			t.Errorf("%s: err = %v", tt.command, err)
+		}
+	}
+}
+
+func TestProfileCredentials(t *testing.T) {
+	dir := t.TempDir()
+	credentialsFile := filepath.Join(dir, "credentials")
+	configFile := filepath.Join(dir, "config")
+	_ = os.WriteFile(credentialsFile, []byte("[default]\naws_access_key_id = DEFAULT\naws_secret_access_key = DEFAULT_SECRET\n\n[prod]\naws_access_key_id = PROD\naws_secret_access_key = PROD_SECRET\naws_session_token = PROD_TOKEN\n"), 0600)
+	_ = os.WriteFile(configFile, []byte("[profile prod]\nregion = us-west-2\naws_access_key_id = IGNORED\n\n[profile dev]\naws_access_key_id = DEV\naws_secret_access_key = DEV_SECRET\n"), 0600)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsFile)
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+	t.Setenv("AWS_PROFILE", "")
+	tests := []struct {
+		profile string
+		id      string
+		token   string
+	}{
+		{"", "DEFAULT", ""},
+		{"prod", "PROD", "PROD_TOKEN"},
+		{"dev", "DEV", ""},
+	}
+	for _, tt := range tests {
+		creds, err := NewProfileCredentials(tt.profile).Retrieve(context.Background())
+		if err != nil {
+			t.Errorf("%q: %v", tt.profile, err)
+			continue
+		}
+		if creds.AccessKeyID != tt.id || creds.SessionToken != tt.token {
+			t.Errorf("%q: creds = %+v", tt.profile, creds)
+		}
+	}
+	if _, err := NewProfileCredentials("missing").Retrieve(context.Background()); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("missing profile: err = %v", err)
+	}
+
+	// 缓存后修改文件不影响已获取的凭证
+	provider := NewProfileCredentials("prod")
+	if _, err := provider.Retrieve(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.WriteFile(credentialsFile, []byte("[prod]\naws_access_key_id = ROTATED\naws_secret_access_key = ROTATED_SECRET\n"), 0600)
+	if creds, _ := provider.Retrieve(context.Background()); creds.AccessKeyID != "PROD" {
+		t.Errorf("cached creds = %+v", creds)
+	}
+	provider.Invalidate()
+	if creds, _ := provider.Retrieve(context.Background()); creds.AccessKeyID != "ROTATED" {
+		t.Errorf("creds after Invalidate = %+v", creds)
+	}
+}
@@ -0,0 +1,111 @@
+package s3
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ResponseError 服务端返回的错误
+type ResponseError struct {
+	// Operation 操作名称,如Put、Head
+	Operation string
+	Bucket    string
+	Key       string
+	// StatusCode http状态码
+	StatusCode int
+	// Code 错误码,如NoSuchKey、AccessDenied,HEAD请求没有响应体时为空
+	Code      string
+	Message   string
+	RequestID string
+	HostID    string
+	// Body 原始响应体
+	Body []byte
+}
+
+// Error 错误信息
+func (e *ResponseError) Error() string {
+	msg := " " + e.Operation
+	if e.Bucket != "" {
+		msg += " Bucket: " + e.Bucket
+	}
+	if e.Key != "" {
+		msg += " Object: " + e.Key
+	}
+	msg += fmt.Sprintf(" StatusCode: %d X-Amz-Request-Id: %s", e.StatusCode, e.RequestID)
+	if e.Code != "" {
+		msg += fmt.Sprintf(" Code: %s Message: %s", e.Code, e.Message)
+	}
+	return msg
+}
+
+// AsResponseError 从err中获取*ResponseError
+func AsResponseError(err error) (*ResponseError, bool) {
+	var respErr *ResponseError
+	ok := errors.As(err, &respErr)
+	return respErr, ok
+}
+
+// IsNotFound 判断是否为bucket、object或分块上传不存在
+func IsNotFound(err error) bool {
+	respErr, ok := AsResponseError(err)
+	if !ok {
+		return false
+	}
+	switch respErr.Code {
+	case "NoSuchKey", "NoSuchBucket", "NoSuchUpload", "NotFound":
+		return true
+	}
+	return respErr.StatusCode == http.StatusNotFound
+}
+
+// IsAccessDenied 判断是否为无权限
+func IsAccessDenied(err error) bool {
+	respErr, ok := AsResponseError(err)
+	if !ok {
+		return false
+	}
+	return respErr.Code == "AccessDenied" || respErr.StatusCode == http.StatusForbidden
+}
+
+// IsPreconditionFailed 判断是否为条件请求不满足
+func IsPreconditionFailed(err error) bool {
+	respErr, ok := AsResponseError(err)
+	if !ok {
+		return false
+	}
+	return respErr.Code == "PreconditionFailed" || respErr.StatusCode == http.StatusPreconditionFailed
+}
+
+// ChecksumError 下载内容的校验和与服务端不一致
+type ChecksumError struct {
+	// Operation 操作名称,如Cat、Get
+	Operation string
+	Bucket    string
+	Key       string
+	// Algorithm 校验和算法,单次上传对象的ETag校验为MD5
+	Algorithm ChecksumAlgorithm
+	Expected  string
+	Actual    string
+}
+
+// Error 错误信息
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf(" %s Bucket: %s Object: %s Error: %s checksum mismatch, expected %s actual %s", e.Operation, e.Bucket, e.Key, e.Algorithm, e.Expected, e.Actual)
+}
+
+// IsChecksumMismatch 判断是否为下载内容校验失败或服务端校验请求体失败
+func IsChecksumMismatch(err error) bool {
+	var checksumErr *ChecksumError
+	if errors.As(err, &checksumErr) {
+		return true
+	}
+	respErr, ok := AsResponseError(err)
+	return ok && (respErr.Code == "BadDigest" || respErr.Code == "InvalidDigest" || respErr.Code == "XAmzContentChecksumMismatch")
+}
+
+// IsErrorCode 判断错误码
+func IsErrorCode(err error, code string) bool {
+	respErr, ok := AsResponseError(err)
+	return ok && respErr.Code == code
+}
@@ -1,6 +1,7 @@
 package s3
 
 import (
+	"context"
 	"io"
 	"net/http"
 )
@@ -44,3 +45,82 @@ type Client interface {
 	MoveAllObject(bucket, prefix, source string, options map[string]string, percentChan chan int) (map[string]int, error)
 	DownloadAllObject(bucket, prefix, localDir string, options map[string]string, percentChan chan int) (map[string]int, error)
 }
+
+// ContextClient 支持context的S3客户端接口,ctx的取消和超时会传递到每个http请求、分块任务及批量操作
+type ContextClient interface {
+	Client
+	GetServiceWithContext(ctx context.Context) (*ServiceResult, error)
+	CreateBucketWithContext(ctx context.Context, bucket string, options map[string]string) (http.Header, error)
+	DeleteBucketWithContext(ctx context.Context, bucket string) (http.Header, error)
+	ListPartWithContext(ctx context.Context, bucket string, options map[string]string) (*ListPartsResult, error)
+	DeleteAllPartWithContext(ctx context.Context, bucket, prefix string, options map[string]string, percentChan chan int) (map[string]int, error)
+	GetACLWithContext(ctx context.Context, bucket string) (*AclResult, error)
+	SetACLWithContext(ctx context.Context, bucket string, options map[string]string) (http.Header, error)
+	GetLifecycleWithContext(ctx context.Context, bucket string) (*LifecycleResult, error)
+	SetLifecycleWithContext(ctx context.Context, bucket string, options map[string]string) (http.Header, error)
+	DeleteLifecycleWithContext(ctx context.Context, bucket string) (http.Header, error)
+
+	UploadLargeFileWithContext(ctx context.Context, filePath, bucket, object string, options map[string]string, percentChan chan int) (map[string]interface{}, error)
+	MoveLargeFileWithContext(ctx context.Context, bucket, object, source string, options map[string]string) (map[string]interface{}, error)
+	CopyLargeFileWithContext(ctx context.Context, bucket, object, source string, options map[string]string, percentChan chan int) (map[string]interface{}, error)
+	InitUploadWithContext(ctx context.Context, bucket, object string, options map[string]string) (*InitUploadResult, error)
+	UploadPartWithContext(ctx context.Context, body io.Reader, bodySize int, bucket, object string, partNumber int, uploadID string) (http.Header, error)
+	ListPartsWithContext(ctx context.Context, bucket, object, uploadID string) (*ListUploadPartsResult, error)
+	CancelPartWithContext(ctx context.Context, bucket, object string, uploadID string) (http.Header, error)
+	CopyPartWithContext(ctx context.Context, partRange, bucket, object, source string, partNumber int, uploadID string) (map[string]string, error)
+	CompleteUploadWithContext(ctx context.Context, body []byte, bucket, object, uploadID string, objectSize int) (map[string]interface{}, error)
+
+	SyncLargeFileWithContext(ctx context.Context, toClient ContextClient, bucket, object, source string, options map[string]string, percentChan chan int) (map[string]interface{}, error)
+	SyncAllObjectWithContext(ctx context.Context, toClient ContextClient, bucket, prefix, source string, options map[string]string, percentChan chan int) (map[string]int, error)
+
+	UploadFileWithContext(ctx context.Context, filePath, bucket, object string, options map[string]string) (map[string]interface{}, error)
+	PutWithContext(ctx context.Context, body io.Reader, bodySize int, bucket, object string, options map[string]string) (map[string]interface{}, error)
+	CopyWithContext(ctx context.Context, bucket, object, source string, options map[string]string) (map[string]interface{}, error)
+	DeleteWithContext(ctx context.Context, bucket, object string) (http.Header, error)
+	HeadWithContext(ctx context.Context, bucket, object string) (http.Header, error)
+	GetWithContext(ctx context.Context, bucket, object, localFile string, options map[string]string, percentChan chan int) (map[string]string, error)
+	CatWithContext(ctx context.Context, bucket, object, partRange string, dsc io.Writer) (http.Header, error)
+	UploadFromDirWithContext(ctx context.Context, localDir, bucket, prefix string, options map[string]string, percentChan chan int) (map[string]int, error)
+	ListObjectWithContext(ctx context.Context, bucket string, options map[string]string) (*ListObjectResult, error)
+	CopyAllObjectWithContext(ctx context.Context, bucket, prefix, source string, options map[string]string, percentChan chan int) (map[string]int, error)
+	DeleteAllObjectWithContext(ctx context.Context, bucket, prefix string, options map[string]string, percentChan chan int) (map[string]int, error)
+	MoveAllObjectWithContext(ctx context.Context, bucket, prefix, source string, options map[string]string, percentChan chan int) (map[string]int, error)
+	DownloadAllObjectWithContext(ctx context.Context, bucket, prefix, localDir string, options map[string]string, percentChan chan int) (map[string]int, error)
+}
+
+// OptionsClient 支持类型化参数和类型化结果的S3客户端接口,参数非法时返回ErrInvalidOption,opts为nil时使用默认值
+type OptionsClient interface {
+	ContextClient
+	CreateBucketWithOptions(ctx context.Context, bucket string, opts *BucketOptions) (http.Header, error)
+	ListPartWithOptions(ctx context.Context, bucket string, opts *ListPartOptions) (*ListPartsResult, error)
+	DeleteAllPartWithOptions(ctx context.Context, bucket, prefix string, opts *BulkOptions) (*BulkResult, error)
+	SetACLWithOptions(ctx context.Context, bucket string, opts *BucketOptions) (http.Header, error)
+	SetLifecycleWithOptions(ctx context.Context, bucket string, opts *LifecycleOptions) (http.Header, error)
+
+	UploadLargeFileWithOptions(ctx context.Context, filePath, bucket, object string, opts *MultipartOptions) (*PutResult, error)
+	MoveLargeFileWithOptions(ctx context.Context, bucket, object, source string, opts *MultipartOptions) (*PutResult, error)
+	CopyLargeFileWithOptions(ctx context.Context, bucket, object, source string, opts *MultipartOptions) (*PutResult, error)
+	InitUploadWithOptions(ctx context.Context, bucket, object string, opts *PutOptions) (*InitUploadResult, error)
+	UploadPartWithOptions(ctx context.Context, body io.Reader, bodySize int, bucket, object string, partNumber int, uploadID string, opts *PartOptions) (http.Header, error)
+	ListPartsWithOptions(ctx context.Context, bucket, object, uploadID string, opts *ListPartsOptions) (*ListUploadPartsResult, error)
+	CompleteUploadWithOptions(ctx context.Context, body []byte, bucket, object, uploadID string, opts *CompleteOptions) (*PutResult, error)
+	NewWriter(ctx context.Context, bucket, object string, opts *MultipartOptions) (ObjectWriter, error)
+
+	SyncLargeFileWithOptions(ctx context.Context, toClient OptionsClient, bucket, object, source string, opts *MultipartOptions) (*PutResult, error)
+	SyncAllObjectWithOptions(ctx context.Context, toClient OptionsClient, bucket, prefix, source string, opts *BulkOptions) (*BulkResult, error)
+
+	UploadFileWithOptions(ctx context.Context, filePath, bucket, object string, opts *PutOptions) (*PutResult, error)
+	PutWithOptions(ctx context.Context, body io.Reader, bodySize int, bucket, object string, opts *PutOptions) (*PutResult, error)
+	CopyWithOptions(ctx context.Context, bucket, object, source string, opts *CopyOptions) (*PutResult, error)
+	GetWithOptions(ctx context.Context, bucket, object, localFile string, opts *GetOptions) (*ObjectInfo, error)
+	NewReader(ctx context.Context, bucket, object string, opts *GetOptions) (ObjectReader, error)
+	UploadFromDirWithOptions(ctx context.Context, localDir, bucket, prefix string, opts *BulkOptions) (*BulkResult, error)
+	ListObjectWithOptions(ctx context.Context, bucket string, opts *ListOptions) (*ListObjectResult, error)
+	CopyAllObjectWithOptions(ctx context.Context, bucket, prefix, source string, opts *BulkOptions) (*BulkResult, error)
+	DeleteAllObjectWithOptions(ctx context.Context, bucket, prefix string, opts *BulkOptions) (*BulkResult, error)
+	MoveAllObjectWithOptions(ctx context.Context, bucket, prefix, source string, opts *BulkOptions) (*BulkResult, error)
+	DownloadAllObjectWithOptions(ctx context.Context, bucket, prefix, localDir string, opts *BulkOptions) (*BulkResult, error)
+
+	PresignWithOptions(ctx context.Context, bucket, object string, opts *PresignOptions) (string, error)
+	PresignPostWithContext(ctx context.Context, policy *PostPolicy) (*PostForm, error)
+}
@@ -0,0 +1,157 @@
+package s3
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidOption 参数非法
+var ErrInvalidOption = errors.New("invalid option")
+
+// BucketOptions 创建bucket、设置acl参数
+type BucketOptions struct {
+	// ACL 如private、public-read、public-read-write
+	ACL string
+}
+
+// LifecycleOptions 生命周期规则参数
+type LifecycleOptions struct {
+	// Prefix 规则作用的对象前缀
+	Prefix string
+	// Expiration 对象过期天数
+	Expiration int
+}
+
+// ListPartOptions 分块上传列表参数
+type ListPartOptions struct {
+	Prefix    string
+	Delimiter string
+	KeyMarker string
+	// MaxUploads 单次返回的最大数量,0时使用服务端默认值
+	MaxUploads int
+}
+
+// ListPartsOptions 已上传分块列表参数
+type ListPartsOptions struct {
+	// PartNumberMarker 从大于该编号的分块开始列举
+	PartNumberMarker int
+	// MaxParts 单次返回的最大数量,0时使用服务端默认值
+	MaxParts int
+}
+
+// ListOptions 对象列表参数
+type ListOptions struct {
+	Prefix    string
+	Delimiter string
+	Marker    string
+	// MaxKeys 单次返回的最大数量,0时使用服务端默认值
+	MaxKeys int
+}
+
+// PutOptions 上传参数,也用于UploadFile、InitUpload
+type PutOptions struct {
+	ACL string
+	// Disposition 下载时的文件名
+	Disposition string
+	// Progress 进度监听,InitUpload忽略
+	Progress ProgressListener
+}
+
+// PartOptions 上传分块参数
+type PartOptions struct {
+	// Progress 进度监听
+	Progress ProgressListener
+}
+
+// CopyOptions 复制参数
+type CopyOptions struct {
+	ACL string
+	// Disposition 下载时的文件名
+	Disposition string
+}
+
+// GetOptions 下载参数
+type GetOptions struct {
+	// PartSize 分块大小,0时使用最小分块大小
+	PartSize int64
+	// ThreadNum 并发数,0时使用最大并发数
+	ThreadNum int
+	// Progress 进度监听
+	Progress ProgressListener
+}
+
+// MultipartOptions 分块上传、复制、移动、同步参数
+type MultipartOptions struct {
+	ACL string
+	// Disposition 下载时的文件名
+	Disposition string
+	// PartSize 分块大小,0时使用最大分块大小
+	PartSize int64
+	// ThreadNum 并发数,0时使用最大并发数
+	ThreadNum int
+	// Progress 进度监听
+	Progress ProgressListener
+	// Checkpoint UploadLargeFile的断点文件路径,为空时不使用断点续传
+	// 重新执行时本地文件未修改则跳过已完成的分块并完成同一个分块上传,上传成功后删除
+	Checkpoint string
+	// UploadID UploadLargeFile、CopyLargeFile继续已初始化的分块上传,为空时初始化新的分块上传
+	// 通过ListPartsWithContext获取已上传的分块,大小一致的分块不再上传;UploadLargeFile还会校验分块的MD5
+	UploadID string
+}
+
+// CompleteOptions 完成分块上传参数
+type CompleteOptions struct {
+	// ObjectSize 对象大小,用于填充PutResult.Size
+	ObjectSize int64
+}
+
+// BulkOptions 批量操作参数
+type BulkOptions struct {
+	ACL string
+	// PartSize 单个文件的分块大小,0时使用默认值
+	PartSize int64
+	// ThreadNum 并发数,0时使用最大并发数
+	ThreadNum int
+	// MaxKeys 每次列举的对象数量,0时为1000
+	MaxKeys int
+	// Replace 覆盖已存在且未过期的文件
+	Replace bool
+	// FullPath 保留源前缀之后的完整路径,否则只保留文件名
+	FullPath bool
+	// Suffix 后缀过滤,UploadFromDir只上传匹配的文件,CopyAllObject、MoveAllObject跳过匹配的对象
+	Suffix []string
+	// Expired DeleteAllPart只取消初始化时间超过Expired的分块上传
+	Expired time.Duration
+	// Progress 进度监听,包括每个文件的开始、完成、跳过、失败事件
+	Progress ProgressListener
+}
+
+// PresignOptions 预签名URL参数
+type PresignOptions struct {
+	// Method 请求方法,默认GET
+	Method string
+	// Expires 有效期,默认15分钟
+	Expires time.Duration
+	// Query 附加的查询参数,如response-content-disposition、response-content-type、versionId
+	Query map[string]string
+	// Headers 参与签名的请求头,使用URL时必须携带相同的头,如Content-Type
+	Headers map[string]string
+}
+
+// PostPolicy 浏览器表单上传的POST policy条件
+type PostPolicy struct {
+	Bucket string
+	// Expires 有效期,默认1小时
+	Expires time.Duration
+	// Key 对象名,KeyPrefix非空时可为空,表单中key默认为 KeyPrefix+${filename}
+	Key string
+	// KeyPrefix 允许上传以此开头的任意对象(starts-with)
+	KeyPrefix string
+	// ContentType 上传时必须使用的Content-Type
+	ContentType string
+	// MinSize、MaxSize 文件大小范围(content-length-range),MaxSize为0时不限制
+	MinSize int64
+	MaxSize int64
+	// ACL 如private、public-read
+	ACL string
+}
@@ -0,0 +1,142 @@
+package s3
+
+import (
+	"sync"
+	"time"
+)
+
+// ProgressEventType 进度事件类型
+type ProgressEventType int
+
+const (
+	// TransferStarted 操作开始
+	TransferStarted ProgressEventType = iota
+	// TransferData 传输了数据
+	TransferData
+	// TransferCompleted 操作完成
+	TransferCompleted
+	// TransferFailed 操作失败
+	TransferFailed
+	// FileStarted 批量操作中开始处理一个文件
+	FileStarted
+	// FileCompleted 批量操作中一个文件处理完成
+	FileCompleted
+	// FileSkipped 批量操作中跳过一个文件
+	FileSkipped
+	// FileFailed 批量操作中一个文件处理失败
+	FileFailed
+)
+
+// String 事件类型名称
+func (t ProgressEventType) String() string {
+	switch t {
+	case TransferStarted:
+		return "TransferStarted"
+	case TransferData:
+		return "TransferData"
+	case TransferCompleted:
+		return "TransferCompleted"
+	case TransferFailed:
+		return "TransferFailed"
+	case FileStarted:
+		return "FileStarted"
+	case FileCompleted:
+		return "FileCompleted"
+	case FileSkipped:
+		return "FileSkipped"
+	case FileFailed:
+		return "FileFailed"
+	}
+	return "Unknown"
+}
+
+// ProgressEvent 进度事件
+type ProgressEvent struct {
+	Type ProgressEventType
+	// Key 文件事件对应的对象或本地文件
+	Key string
+	// Err TransferFailed、FileFailed事件的错误
+	Err error
+	// RwBytes 本次事件传输的字节数,连续的TransferData事件可能被合并
+	RwBytes int64
+	// ConsumedBytes 已传输的字节数
+	ConsumedBytes int64
+	// TotalBytes 总字节数,批量操作随列举逐步增加,跳过的文件不计入
+	TotalBytes int64
+	// FinishedFiles 已完成、跳过及失败的文件数
+	FinishedFiles int
+	// TotalFiles 文件总数,单个文件的操作为0
+	TotalFiles int
+	// Speed 平均速度,字节/秒
+	Speed float64
+	// ETA 预计剩余时间,无法估算时为0
+	ETA time.Duration
+}
+
+// Percent 已传输的百分比,总字节数未知时为0
+func (e *ProgressEvent) Percent() float64 {
+	if e.TotalBytes <= 0 {
+		return 0
+	}
+	return float64(e.ConsumedBytes) * 100 / float64(e.TotalBytes)
+}
+
+// ProgressListener 进度监听
+// 事件在独立的goroutine中按顺序回调,回调慢时合并TransferData事件,不会阻塞传输;
+// 操作返回前会等待所有事件回调完成,最后一个事件为TransferCompleted或TransferFailed
+type ProgressListener interface {
+	ProgressChanged(event *ProgressEvent)
+}
+
+// ProgressFunc 函数形式的ProgressListener
+type ProgressFunc func(event *ProgressEvent)
+
+// ProgressChanged 回调进度事件
+func (f ProgressFunc) ProgressChanged(event *ProgressEvent) {
+	f(event)
+}
+
+// ProgressChan 将进度事件按顺序发送到ch的ProgressListener,ch由调用方读取和关闭
+// 事件先进入队列再由独立的goroutine发送,不阻塞传输也不丢弃事件,ch未及时读取时合并TransferData事件;
+// 调用方应读到TransferCompleted或TransferFailed后再关闭ch
+func ProgressChan(ch chan<- *ProgressEvent) ProgressListener {
+	return &progressChan{ch: ch}
+}
+
+type progressChan struct {
+	ch      chan<- *ProgressEvent
+	mu      sync.Mutex
+	queue   []*ProgressEvent
+	sending bool
+}
+
+func (p *progressChan) ProgressChanged(event *ProgressEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if n := len(p.queue); n > 0 && event.Type == TransferData && p.queue[n-1].Type == TransferData {
+		merged := *event
+		merged.RwBytes += p.queue[n-1].RwBytes
+		p.queue[n-1] = &merged
+	} else {
+		p.queue = append(p.queue, event)
+	}
+	if !p.sending {
+		p.sending = true
+		go p.send()
+	}
+}
+
+func (p *progressChan) send() {
+	for {
+		p.mu.Lock()
+		if len(p.queue) == 0 {
+			p.sending = false
+			p.mu.Unlock()
+			return
+		}
+		event := p.queue[0]
+		p.queue = p.queue[1:]
+		p.mu.Unlock()
+		p.ch <- event
+	}
+}
@@ -0,0 +1,50 @@
+package s3
+
+import "testing"
+
+func TestProgressChan(t *testing.T) {
+	ch := make(chan *ProgressEvent)
+	listener := ProgressChan(ch)
+	// 无人读取时不阻塞
+	listener.ProgressChanged(&ProgressEvent{Type: TransferStarted})
+	listener.ProgressChanged(&ProgressEvent{Type: TransferData, RwBytes: 1})
+	listener.ProgressChanged(&ProgressEvent{Type: TransferData, RwBytes: 2, ConsumedBytes: 3})
+	listener.ProgressChanged(&ProgressEvent{Type: TransferCompleted, ConsumedBytes: 3})
+
+	if event := <-ch; event.Type != TransferStarted {
+		t.Fatalf("event = %v, want TransferStarted", event.Type)
+	}
+	var rwBytes int64
+	for {
+		event := <-ch
+		if event.Type == TransferCompleted {
+			break
+		}
+		if event.Type != TransferData {
+			t.Fatalf("event = %v, want TransferData", event.Type)
+		}
+		rwBytes += event.RwBytes
+	}
+	if rwBytes != 3 {
+		t.Errorf("RwBytes = %d, want 3", rwBytes)
+	}
+	close(ch)
+}
+
+func TestProgressEventPercent(t *testing.T) {
+	tests := []struct {
+		consumed, total int64
+		want            float64
+	}{
+		{0, 0, 0},
+		{50, 0, 0},
+		{25, 100, 25},
+		{100, 100, 100},
+	}
+	for _, tt := range tests {
+		event := &ProgressEvent{ConsumedBytes: tt.consumed, TotalBytes: tt.total}
+		if got := event.Percent(); got != tt.want {
+			t.Errorf("Percent(%d/%d) = %v, want %v", tt.consumed, tt.total, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package s3
+
+import (
+	"strconv"
+	"time"
+)
+
+// PutResult 上传、复制、分块上传完成的结果
+type PutResult struct {
+	Bucket   string
+	Key      string
+	ETag     string
+	Size     int64
+	Location string
+	// StatusCode 最后一个请求的http状态码
+	StatusCode int
+	RequestID  string
+	// Duration 整个操作的耗时
+	Duration time.Duration
+}
+
+// Map 转换为旧接口的map结果
+func (r *PutResult) Map() map[string]interface{} {
+	return map[string]interface{}{
+		"X-Amz-Request-Id": r.RequestID,
+		"StatusCode":       strconv.Itoa(r.StatusCode),
+		"Location":         r.Location,
+		"Size":             int(r.Size),
+		"Bucket":           r.Bucket,
+		"ETag":             r.ETag,
+		"Key":              r.Key,
+	}
+}
+
+// ObjectInfo 对象信息
+type ObjectInfo struct {
+	Bucket       string
+	Key          string
+	Size         int64
+	ETag         string
+	ContentType  string
+	LastModified time.Time
+	// LocalFile Get下载到的本地文件
+	LocalFile string
+}
+
+// Map 转换为旧接口Get的map结果
+func (o *ObjectInfo) Map() map[string]string {
+	return map[string]string{"Object": o.Key, "Localfile": o.LocalFile}
+}
+
+// BulkError 批量操作中失败的对象
+type BulkError struct {
+	Key string
+	Err error
+}
+
+// BulkResult 批量操作的结果,操作出错时返回已处理部分的统计
+type BulkResult struct {
+	// Total 列举到的对象或文件数量
+	Total  int
+	Finish int
+	Skip   int
+	// Size 完成的对象的总大小
+	Size     int64
+	Duration time.Duration
+	// Skipped 跳过的对象
+	Skipped []string
+	// Failed 失败的对象
+	Failed []BulkError
+}
+
+// Map 转换为旧接口的map结果
+func (r *BulkResult) Map() map[string]int {
+	return map[string]int{"Total": r.Total, "Finish": r.Finish, "Skip": r.Skip, "Size": int(r.Size)}
+}
+
+// PostForm 浏览器表单上传的地址及字段
+type PostForm struct {
+	// URL 表单的action
+	URL string
+	// Fields 表单字段,文件字段file需放在所有字段之后
+	Fields map[string]string
+}
@@ -0,0 +1,116 @@
+package s3
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryAttempt 一次失败请求的信息
+type RetryAttempt struct {
+	// Attempt 已重试的次数,首次请求失败时为0
+	Attempt int
+	// StatusCode 响应状态码,网络错误时为0
+	StatusCode int
+	// Code 响应中的错误码,如SlowDown、InternalError
+	Code string
+	// RetryAfter 响应头Retry-After指定的等待时间
+	RetryAfter time.Duration
+	// Err 网络错误
+	Err error
+}
+
+// RetryPolicy 重试策略
+type RetryPolicy interface {
+	// Retry 判断失败的请求是否重试,返回重试前的等待时间
+	Retry(attempt *RetryAttempt) (time.Duration, bool)
+}
+
+// 可重试的错误码
+var retryableCodes = map[string]bool{
+	"SlowDown":            true,
+	"RequestTimeout":      true,
+	"InternalError":       true,
+	"ServiceUnavailable":  true,
+	"Throttling":          true,
+	"ThrottlingException": true,
+	"RequestThrottled":    true,
+	"TooManyRequests":     true,
+}
+
+// IsRetryable 判断失败的请求是否可重试
+// 网络错误(证书错误、ctx取消除外)、429、500、502、503、504及SlowDown、RequestTimeout、InternalError等错误码可重试
+func IsRetryable(attempt *RetryAttempt) bool {
+	if attempt.Err != nil {
+		return isRetryableError(attempt.Err)
+	}
+	if retryableCodes[attempt.Code] {
+		return true
+	}
+	switch attempt.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func isRetryableError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var verifyErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	if errors.As(err, &verifyErr) || errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return false
+	}
+	return true
+}
+
+// DefaultRetryPolicy 默认重试策略,指数退避加随机抖动,并遵循Retry-After(最多等待MaxDelay)
+type DefaultRetryPolicy struct {
+	// MaxRetryNum 最大重试次数
+	MaxRetryNum int
+	// BaseDelay 首次重试的退避基数
+	BaseDelay time.Duration
+	// MaxDelay 单次退避的上限
+	MaxDelay time.Duration
+}
+
+// NewRetryPolicy 创建默认重试策略
+func NewRetryPolicy(maxRetryNum int) *DefaultRetryPolicy {
+	return &DefaultRetryPolicy{
+		MaxRetryNum: maxRetryNum,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    20 * time.Second,
+	}
+}
+
+// Retry 判断失败的请求是否重试,返回重试前的等待时间
+func (p *DefaultRetryPolicy) Retry(attempt *RetryAttempt) (time.Duration, bool) {
+	if attempt.Attempt >= p.MaxRetryNum || !IsRetryable(attempt) {
+		return 0, false
+	}
+	//full jitter: [0, min(MaxDelay, BaseDelay*2^Attempt))
+	backoff := p.BaseDelay
+	for i := 0; i < attempt.Attempt && backoff < p.MaxDelay; i++ {
+		backoff *= 2
+	}
+	if backoff > p.MaxDelay {
+		backoff = p.MaxDelay
+	}
+	var delay time.Duration
+	if backoff > 0 {
+		delay = time.Duration(rand.Int63n(int64(backoff)))
+	}
+	//Retry-After最多等待MaxDelay
+	if attempt.RetryAfter > delay {
+		delay = min(attempt.RetryAfter, p.MaxDelay)
+	}
+	return delay, true
+}
@@ -0,0 +1,107 @@
+package s3
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name    string
+		attempt RetryAttempt
+		want    bool
+	}{
+		{"network error", RetryAttempt{Err: io.ErrUnexpectedEOF}, true},
+		{"deadline", RetryAttempt{Err: context.DeadlineExceeded}, true},
+		{"canceled", RetryAttempt{Err: context.Canceled}, false},
+		{"wrapped canceled", RetryAttempt{Err: fmt.Errorf("get: %w", context.Canceled)}, false},
+		{"unknown authority", RetryAttempt{Err: fmt.Errorf("tls: %w", x509.UnknownAuthorityError{})}, false},
+		{"hostname", RetryAttempt{Err: x509.HostnameError{Host: "example.com"}}, false},
+		{"429", RetryAttempt{StatusCode: http.StatusTooManyRequests}, true},
+		{"500", RetryAttempt{StatusCode: http.StatusInternalServerError}, true},
+		{"502", RetryAttempt{StatusCode: http.StatusBadGateway}, true},
+		{"503", RetryAttempt{StatusCode: http.StatusServiceUnavailable}, true},
+		{"504", RetryAttempt{StatusCode: http.StatusGatewayTimeout}, true},
+		{"501", RetryAttempt{StatusCode: http.StatusNotImplemented}, false},
+		{"SlowDown", RetryAttempt{StatusCode: http.StatusServiceUnavailable, Code: "SlowDown"}, true},
+		{"RequestTimeout", RetryAttempt{StatusCode: http.StatusBadRequest, Code: "RequestTimeout"}, true},
+		{"Throttling 400", RetryAttempt{StatusCode: http.StatusBadRequest, Code: "Throttling"}, true},
+		{"AccessDenied", RetryAttempt{StatusCode: http.StatusForbidden, Code: "AccessDenied"}, false},
+		{"NoSuchKey", RetryAttempt{StatusCode: http.StatusNotFound, Code: "NoSuchKey"}, false},
+		{"BadDigest", RetryAttempt{StatusCode: http.StatusBadRequest, Code: "BadDigest"}, false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryable(&tt.attempt); got != tt.want {
+			t.Errorf("%s: IsRetryable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultRetryPolicyJitter(t *testing.T) {
+	p := &DefaultRetryPolicy{MaxRetryNum: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		attempt int
+		backoff time.Duration
+	}{
+		{0, 100 * time.Millisecond},
+		{1, 200 * time.Millisecond},
+		{2, 400 * time.Millisecond},
+		{3, 800 * time.Millisecond},
+		{4, time.Second},
+		{9, time.Second},
+	}
+	for _, tt := range tests {
+		var maxDelay time.Duration
+		for i := 0; i < 200; i++ {
+			delay, retry := p.Retry(&RetryAttempt{Attempt: tt.attempt, StatusCode: http.StatusServiceUnavailable})
+			if !retry {
+				t.Fatalf("attempt %d: not retried", tt.attempt)
+			}
+			if delay < 0 || delay >= tt.backoff {
+				t.Fatalf("attempt %d: delay %v out of [0, %v)", tt.attempt, delay, tt.backoff)
+			}
+			maxDelay = max(maxDelay, delay)
+		}
+		// 200次抽样全部落在区间前半部分的概率可忽略
+		if maxDelay < tt.backoff/2 {
+			t.Errorf("attempt %d: max delay %v, want jitter over [0, %v)", tt.attempt, maxDelay, tt.backoff)
+		}
+	}
+}
+
+func TestDefaultRetryPolicy(t *testing.T) {
+	p := &DefaultRetryPolicy{MaxRetryNum: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		name     string
+		attempt  RetryAttempt
+		retry    bool
+		minDelay time.Duration
+		maxDelay time.Duration
+	}{
+		{"retryable", RetryAttempt{Attempt: 0, StatusCode: http.StatusInternalServerError}, true, 0, time.Second},
+		{"last retry", RetryAttempt{Attempt: 2, StatusCode: http.StatusInternalServerError}, true, 0, time.Second},
+		{"exhausted", RetryAttempt{Attempt: 3, StatusCode: http.StatusInternalServerError}, false, 0, 0},
+		{"not retryable", RetryAttempt{Attempt: 0, StatusCode: http.StatusForbidden}, false, 0, 0},
+		// Retry-After大于退避时间时按Retry-After等待
+		{"retry after", RetryAttempt{Attempt: 0, StatusCode: http.StatusServiceUnavailable, RetryAfter: 500 * time.Millisecond}, true, 500 * time.Millisecond, 500 * time.Millisecond},
+		// Retry-After最多等待MaxDelay
+		{"retry after over max", RetryAttempt{Attempt: 0, StatusCode: http.StatusServiceUnavailable, RetryAfter: time.Hour}, true, time.Second, time.Second},
+		{"network error", RetryAttempt{Attempt: 1, Err: errors.New("connection reset")}, true, 0, time.Second},
+	}
+	for _, tt := range tests {
+		delay, retry := p.Retry(&tt.attempt)
+		if retry != tt.retry {
+			t.Errorf("%s: retry = %v, want %v", tt.name, retry, tt.retry)
+			continue
+		}
+		if delay < tt.minDelay || delay > tt.maxDelay {
+			t.Errorf("%s: delay = %v, want [%v, %v]", tt.name, delay, tt.minDelay, tt.maxDelay)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package s3
+
+import "io"
+
+// ObjectWriter 流式上传对象,Write、Close不可并发调用
+type ObjectWriter interface {
+	io.WriteCloser
+	// CloseWithError 放弃上传并取消已初始化的分块上传,之后的Write返回err
+	CloseWithError(err error) error
+	// Result Close成功后返回上传结果,否则返回nil
+	Result() *PutResult
+}
+
+// ObjectReader 按Range分块读取对象,Read、Seek不可并发调用,ReadAt可并发调用
+type ObjectReader interface {
+	io.ReadSeekCloser
+	io.ReaderAt
+	// Info 打开时HEAD获取的对象信息
+	Info() *ObjectInfo
+}
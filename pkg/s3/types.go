@@ -56,6 +56,22 @@ type ListPartsResult struct {
 	} `xml:"Upload"`
 }
 
+// ListUploadPartsResult 获取分块上传已上传分块结果
+type ListUploadPartsResult struct {
+	Bucket               string `xml:"Bucket"`
+	Key                  string `xml:"Key"`
+	UploadID             string `xml:"UploadId"`
+	NextPartNumberMarker int    `xml:"NextPartNumberMarker"`
+	IsTruncated          string `xml:"IsTruncated"`
+	Part                 []struct {
+		PartNumber   int    `xml:"PartNumber"`
+		LastModified string `xml:"LastModified"`
+		ETag         string `xml:"ETag"`
+		Size         int64  `xml:"Size"`
+		PartChecksum
+	} `xml:"Part"`
+}
+
 // InitUploadResult 初始化上传结果
 type InitUploadResult struct {
 	Bucket   string `xml:"Bucket"`
@@ -74,6 +90,16 @@ type CompleteUploadResult struct {
 // CopyPartResult 复制分片结果
 type CopyPartResult struct {
 	ETag string `xml:"ETag"`
+	PartChecksum
+}
+
+// PartChecksum 分块的附加校验和,只有使用的算法非空
+type PartChecksum struct {
+	ChecksumCRC32     string `xml:"ChecksumCRC32"`
+	ChecksumCRC32C    string `xml:"ChecksumCRC32C"`
+	ChecksumSHA1      string `xml:"ChecksumSHA1"`
+	ChecksumSHA256    string `xml:"ChecksumSHA256"`
+	ChecksumCRC64NVME string `xml:"ChecksumCRC64NVME"`
 }
 
 // CopyObjectResult COPY结果
@@ -109,8 +135,22 @@ type ListObjectContents struct {
 	StorageClass string `xml:"StorageClass"`
 }
 
+// DeleteResult 批量删除结果
+type DeleteResult struct {
+	Deleted []struct {
+		Key string `xml:"Key"`
+	} `xml:"Deleted"`
+	Error []struct {
+		Key     string `xml:"Key"`
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	} `xml:"Error"`
+}
+
 // Error 错误信息
 type Error struct {
-	Code    string `xml:"Code"`
-	Message string `xml:"Message"`
+	Code      string `xml:"Code"`
+	Message   string `xml:"Message"`
+	RequestID string `xml:"RequestId"`
+	HostID    string `xml:"HostId"`
 }
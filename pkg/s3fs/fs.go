@@ -0,0 +1,268 @@
+package s3fs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shideqin/go-s3-sdk/pkg/internal"
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
+// FS 以bucket中的前缀为根目录的只读文件系统,实现fs.FS、fs.ReadDirFS、fs.StatFS、fs.ReadFileFS
+// 目录对应ListObject的delimiter=/及CommonPrefixes,文件信息对应Head,读取文件对应Range方式的Cat
+type FS struct {
+	ctx    context.Context
+	client s3.OptionsClient
+	bucket string
+	prefix string
+	// ReadOptions 打开文件时NewReader的参数,nil时使用默认值
+	ReadOptions *s3.GetOptions
+}
+
+var (
+	_ fs.ReadDirFS  = (*FS)(nil)
+	_ fs.StatFS     = (*FS)(nil)
+	_ fs.ReadFileFS = (*FS)(nil)
+)
+
+// New 创建文件系统,prefix为根目录对应的对象前缀,可为空;所有请求使用ctx
+func New(ctx context.Context, client s3.OptionsClient, bucket, prefix string) *FS {
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return &FS{ctx: ctx, client: client, bucket: bucket, prefix: prefix}
+}
+
+// key name对应的对象名
+func (f *FS) key(name string) string {
+	if name == "." {
+		return strings.TrimSuffix(f.prefix, "/")
+	}
+	return f.prefix + name
+}
+
+// dirPrefix name作为目录时列举使用的前缀
+func (f *FS) dirPrefix(name string) string {
+	if name == "." {
+		return f.prefix
+	}
+	return f.prefix + name + "/"
+}
+
+// Open 打开文件或目录,文件实现io.Seeker及io.ReaderAt
+func (f *FS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if name != "." {
+		reader, err := f.client.NewReader(f.ctx, f.bucket, f.key(name), f.ReadOptions)
+		if err == nil {
+			return &file{ObjectReader: reader, info: newFileInfo(name, reader.Info())}, nil
+		}
+		if !s3.IsNotFound(err) {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+	}
+	entries, err := f.readDir(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &dir{info: &dirInfo{name: path.Base(name)}, entries: entries}, nil
+}
+
+// Stat 文件使用Head获取信息,不存在时判断是否为目录
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	if name != "." {
+		header, err := f.client.HeadWithContext(f.ctx, f.bucket, f.key(name))
+		if err == nil {
+			return newFileInfo(name, internal.NewObjectInfo(f.bucket, f.key(name), header)), nil
+		}
+		if !s3.IsNotFound(err) {
+			return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+		}
+	}
+	if err := f.dirExists(name); err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	return &dirInfo{name: path.Base(name)}, nil
+}
+
+// ReadFile 一次请求读取整个文件
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: errIsDir}
+	}
+	body := &bytes.Buffer{}
+	_, err := f.client.CatWithContext(f.ctx, f.bucket, f.key(name), "", body)
+	if err == nil {
+		return body.Bytes(), nil
+	}
+	if !s3.IsNotFound(err) {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: err}
+	}
+	if dErr := f.dirExists(name); dErr == nil {
+		err = errIsDir
+	} else {
+		err = dErr
+	}
+	return nil, &fs.PathError{Op: "readfile", Path: name, Err: err}
+}
+
+// ReadDir 列举目录,按文件名排序
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	entries, err := f.readDir(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	return entries, nil
+}
+
+var errIsDir = errors.New("is a directory")
+
+// dirExists 前缀下存在对象时目录存在,根目录总是存在
+func (f *FS) dirExists(name string) error {
+	if name == "." {
+		return nil
+	}
+	list, err := f.client.ListObjectWithOptions(f.ctx, f.bucket, &s3.ListOptions{Prefix: f.dirPrefix(name), Delimiter: "/", MaxKeys: 1})
+	if err != nil {
+		return err
+	}
+	if len(list.Contents) == 0 && len(list.CommonPrefixes) == 0 {
+		return fs.ErrNotExist
+	}
+	return nil
+}
+
+// readDir 分页列举目录下的文件及子目录,跳过不是合法文件名的对象及目录本身的占位对象
+func (f *FS) readDir(name string) ([]fs.DirEntry, error) {
+	prefix := f.dirPrefix(name)
+	var entries []fs.DirEntry
+	var marker string
+	var found bool
+	for {
+		list, err := f.client.ListObjectWithOptions(f.ctx, f.bucket, &s3.ListOptions{Prefix: prefix, Delimiter: "/", Marker: marker})
+		if err != nil {
+			return nil, err
+		}
+		found = found || len(list.CommonPrefixes) > 0 || len(list.Contents) > 0
+		for _, v := range list.CommonPrefixes {
+			if entryName := strings.TrimSuffix(strings.TrimPrefix(v.Prefix, prefix), "/"); validName(entryName) {
+				entries = append(entries, fs.FileInfoToDirEntry(&dirInfo{name: entryName}))
+			}
+			marker = max(marker, v.Prefix)
+		}
+		for _, v := range list.Contents {
+			if entryName := strings.TrimPrefix(v.Key, prefix); validName(entryName) {
+				lastModified, _ := time.Parse(time.RFC3339, v.LastModified)
+				entries = append(entries, fs.FileInfoToDirEntry(&fileInfo{name: entryName, size: int64(v.Size), modTime: lastModified.Truncate(time.Second).UTC()}))
+			}
+			marker = max(marker, v.Key)
+		}
+		if list.IsTruncated != "true" || marker == "" {
+			break
+		}
+	}
+	if !found && name != "." {
+		return nil, fs.ErrNotExist
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.Contains(name, "/")
+}
+
+// file 对象文件
+type file struct {
+	s3.ObjectReader
+	info *fileInfo
+}
+
+func (f *file) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+// dir 目录,ReadDir返回打开时列举的内容
+type dir struct {
+	info    *dirInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errIsDir}
+}
+
+func (d *dir) Close() error {
+	return nil
+}
+
+// ReadDir n>0时最多返回n个,没有更多时返回io.EOF;n<=0时返回剩余的全部
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(rest))
+	d.offset += n
+	return rest[:n], nil
+}
+
+// fileInfo 文件信息
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func newFileInfo(name string, info *s3.ObjectInfo) *fileInfo {
+	return &fileInfo{name: path.Base(name), size: info.Size, modTime: info.LastModified.UTC()}
+}
+
+func (i *fileInfo) Name() string       { return i.name }
+func (i *fileInfo) Size() int64        { return i.size }
+func (i *fileInfo) Mode() fs.FileMode  { return 0444 }
+func (i *fileInfo) ModTime() time.Time { return i.modTime }
+func (i *fileInfo) IsDir() bool        { return false }
+func (i *fileInfo) Sys() any           { return nil }
+
+// dirInfo 目录信息,对象存储没有目录的修改时间
+type dirInfo struct {
+	name string
+}
+
+func (i *dirInfo) Name() string       { return i.name }
+func (i *dirInfo) Size() int64        { return 0 }
+func (i *dirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0555 }
+func (i *dirInfo) ModTime() time.Time { return time.Time{} }
+func (i *dirInfo) IsDir() bool        { return true }
+func (i *dirInfo) Sys() any           { return nil }
@@ -0,0 +1,311 @@
+package s3fs
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/shideqin/go-s3-sdk/pkg/providers/v4"
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
+var testModTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// fakeBucket 基于httptest的bucket,支持分页列举、HEAD及Range读取
+// 每页最多返回pageSize个结果,用于测试分页
+type fakeBucket struct {
+	name     string
+	objects  map[string]string
+	pageSize int
+
+	mu        sync.Mutex
+	lists     int
+	rangeGets int
+}
+
+func (b *fakeBucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != b.name {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, "<Error><Code>NoSuchBucket</Code></Error>")
+		return
+	}
+	if key == "" && r.Method == http.MethodGet {
+		b.list(w, r.URL.Query())
+		return
+	}
+	data, ok := b.objects[key]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		if r.Method != http.MethodHead {
+			_, _ = io.WriteString(w, "<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>")
+		}
+		return
+	}
+	sum := md5.Sum([]byte(data))
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+	w.Header().Set("Last-Modified", testModTime.Format(http.TimeFormat))
+	if rng := r.Header.Get("Range"); rng != "" && r.Method == http.MethodGet {
+		var start, end int
+		if _, err := fmt.Sscanf(rng, "bytes=%d-%d", &start, &end); err != nil || start >= len(data) {
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		end = min(end, len(data)-1)
+		b.mu.Lock()
+		b.rangeGets++
+		b.mu.Unlock()
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(data)))
+		w.Header().Set("Content-Length", strconv.Itoa(end-start+1))
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = io.WriteString(w, data[start:end+1])
+		return
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	if r.Method == http.MethodGet {
+		_, _ = io.WriteString(w, data)
+	}
+}
+
+func (b *fakeBucket) list(w http.ResponseWriter, query url.Values) {
+	b.mu.Lock()
+	b.lists++
+	b.mu.Unlock()
+	prefix, delimiter, marker := query.Get("prefix"), query.Get("delimiter"), query.Get("marker")
+	maxKeys := b.pageSize
+	if n, err := strconv.Atoi(query.Get("max-keys")); err == nil && n > 0 {
+		maxKeys = min(maxKeys, n)
+	}
+	keys := make([]string, 0, len(b.objects))
+	for k := range b.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	result := &s3.ListObjectResult{Name: b.name, Prefix: prefix, Marker: marker, Delimiter: delimiter, IsTruncated: "false"}
+	var count int
+	var last string
+	for _, k := range keys {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		entry, isPrefix := k, false
+		if i := strings.Index(k[len(prefix):], delimiter); delimiter != "" && i >= 0 {
+			entry, isPrefix = k[:len(prefix)+i+len(delimiter)], true
+		}
+		if entry <= marker || entry == last {
+			continue
+		}
+		if count == maxKeys {
+			result.IsTruncated = "true"
+			break
+		}
+		last = entry
+		if isPrefix {
+			result.CommonPrefixes = append(result.CommonPrefixes, s3.ListObjectPrefixes{Prefix: entry})
+		} else {
+			result.Contents = append(result.Contents, s3.ListObjectContents{Key: k, Size: len(b.objects[k]), LastModified: testModTime.Format(time.RFC3339)})
+		}
+		count++
+	}
+	body, _ := xml.Marshal(result)
+	_, _ = w.Write(body)
+}
+
+func newTestFS(t *testing.T, pageSize int) (*FS, *fakeBucket) {
+	bucket := &fakeBucket{
+		name:     "bucket",
+		pageSize: pageSize,
+		objects: map[string]string{
+			"root/a.txt":          "hello, world\n",
+			"root/b/":             "",
+			"root/b/c.txt":        "0123456789abcdefghijklmnopqrstuvwxyz",
+			"root/b/d.txt":        "d",
+			"root/b/e/f.txt":      "nested file",
+			"root/b/e/g.txt":      "",
+			"root/h/i.txt":        "i",
+			"root/h/j.txt":        "j",
+			"root/h/k.txt":        "k",
+			"root/h/l.txt":        "l",
+			"root/h/m.txt":        "m",
+			"root/bad/../x.txt":   "skipped",
+			"rootless.txt":        "outside root",
+			"other/root/a.txt":    "outside root",
+			"root/z-last-file.md": strings.Repeat("z", 100),
+		},
+	}
+	srv := httptest.NewServer(bucket)
+	t.Cleanup(srv.Close)
+	client := v4.New(srv.URL, "id", "secret", s3.WithPathStyle(), s3.WithPartSize(4, 1<<20), s3.WithMaxRetryNum(0))
+	fsys := New(context.Background(), client, "bucket", "/root/")
+	fsys.ReadOptions = &s3.GetOptions{PartSize: 4, ThreadNum: 2}
+	return fsys, bucket
+}
+
+func TestFS(t *testing.T) {
+	fsys, bucket := newTestFS(t, 2)
+	if err := fstest.TestFS(fsys, "a.txt", "b/c.txt", "b/d.txt", "b/e/f.txt", "b/e/g.txt", "h/m.txt", "z-last-file.md"); err != nil {
+		t.Fatal(err)
+	}
+	if bucket.lists == 0 || bucket.rangeGets == 0 {
+		t.Errorf("lists = %d, range gets = %d", bucket.lists, bucket.rangeGets)
+	}
+}
+
+func TestFSReadDir(t *testing.T) {
+	fsys, bucket := newTestFS(t, 2)
+	tests := []struct {
+		name  string
+		want  []string
+		pages int
+	}{
+		// 跳过目录占位对象及不合法的文件名
+		{".", []string{"a.txt", "b/", "bad/", "h/", "z-last-file.md"}, 3},
+		{"b", []string{"c.txt", "d.txt", "e/"}, 2},
+		{"h", []string{"i.txt", "j.txt", "k.txt", "l.txt", "m.txt"}, 3},
+	}
+	for _, tt := range tests {
+		bucket.lists = 0
+		entries, err := fsys.ReadDir(tt.name)
+		if err != nil {
+			t.Fatalf("ReadDir(%q): %v", tt.name, err)
+		}
+		var got []string
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() {
+				name += "/"
+			}
+			got = append(got, name)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("ReadDir(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+		if bucket.lists != tt.pages {
+			t.Errorf("ReadDir(%q) listed %d pages, want %d", tt.name, bucket.lists, tt.pages)
+		}
+	}
+	if _, err := fsys.ReadDir("missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ReadDir(missing) err = %v", err)
+	}
+}
+
+func TestFSStat(t *testing.T) {
+	fsys, _ := newTestFS(t, 100)
+	tests := []struct {
+		name  string
+		size  int64
+		isDir bool
+		err   error
+	}{
+		{".", 0, true, nil},
+		{"a.txt", 13, false, nil},
+		{"b", 0, true, nil},
+		{"b/e", 0, true, nil},
+		{"b/e/g.txt", 0, false, nil},
+		{"missing", 0, false, fs.ErrNotExist},
+		{"b/missing.txt", 0, false, fs.ErrNotExist},
+		{"/a.txt", 0, false, fs.ErrInvalid},
+	}
+	for _, tt := range tests {
+		info, err := fsys.Stat(tt.name)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Stat(%q) err = %v, want %v", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Stat(%q): %v", tt.name, err)
+			continue
+		}
+		if info.IsDir() != tt.isDir || info.Size() != tt.size {
+			t.Errorf("Stat(%q) = dir %v size %d, want dir %v size %d", tt.name, info.IsDir(), info.Size(), tt.isDir, tt.size)
+		}
+		if !tt.isDir && !info.ModTime().Equal(testModTime) {
+			t.Errorf("Stat(%q) ModTime = %v, want %v", tt.name, info.ModTime(), testModTime)
+		}
+	}
+	if _, err := fsys.ReadFile("b"); err == nil || !strings.Contains(err.Error(), "is a directory") {
+		t.Errorf("ReadFile(b) err = %v", err)
+	}
+}
+
+func TestFSOpenSeekReadAt(t *testing.T) {
+	fsys, bucket := newTestFS(t, 100)
+	const content = "0123456789abcdefghijklmnopqrstuvwxyz"
+	f, err := fsys.Open("b/c.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	seeker, ok := f.(io.ReadSeeker)
+	if !ok {
+		t.Fatal("file does not implement io.Seeker")
+	}
+	readerAt, ok := f.(io.ReaderAt)
+	if !ok {
+		t.Fatal("file does not implement io.ReaderAt")
+	}
+
+	buf := make([]byte, 6)
+	if n, err := readerAt.ReadAt(buf, 10); err != nil || string(buf[:n]) != content[10:16] {
+		t.Errorf("ReadAt(10) = %q, %v", buf[:n], err)
+	}
+	// 跨越结尾时返回已读取的内容及io.EOF
+	if n, err := readerAt.ReadAt(buf, 33); err != io.EOF || string(buf[:n]) != content[33:] {
+		t.Errorf("ReadAt(33) = %q, %v", buf[:n], err)
+	}
+
+	seeks := []struct {
+		offset int64
+		whence int
+		pos    int64
+	}{
+		{5, io.SeekStart, 5},
+		{3, io.SeekCurrent, 8},
+		{-4, io.SeekEnd, 32},
+	}
+	for _, s := range seeks {
+		pos, err := seeker.Seek(s.offset, s.whence)
+		if err != nil || pos != s.pos {
+			t.Fatalf("Seek(%d, %d) = %d, %v, want %d", s.offset, s.whence, pos, err, s.pos)
+		}
+	}
+	rest, err := io.ReadAll(seeker)
+	if err != nil || string(rest) != content[32:] {
+		t.Errorf("read after Seek = %q, %v", rest, err)
+	}
+	if _, err = seeker.Seek(-1, io.SeekStart); err == nil {
+		t.Error("Seek to negative offset succeeded")
+	}
+	if _, err = seeker.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	all, err := io.ReadAll(seeker)
+	if err != nil || string(all) != content {
+		t.Errorf("read after rewind = %q, %v", all, err)
+	}
+	// 分块大小为4,读取需要多个Range请求
+	if bucket.rangeGets < len(content)/4 {
+		t.Errorf("range gets = %d, want >= %d", bucket.rangeGets, len(content)/4)
+	}
+
+	if _, err = fsys.Open("missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Open(missing.txt) err = %v", err)
+	}
+}
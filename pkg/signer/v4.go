@@ -0,0 +1,215 @@
+package signer
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shideqin/go-s3-sdk/pkg/s3"
+)
+
+const (
+	v4Algorithm       = "AWS4-HMAC-SHA256"
+	v4Request         = "aws4_request"
+	v4DateTimeFormat  = "20060102T150405Z"
+	v4DateFormat      = "20060102"
+	emptyStringSHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	// UnsignedPayload 不对请求体签名
+	UnsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// 不参与签名的请求头
+var v4IgnoredHeaders = map[string]bool{
+	"authorization":   true,
+	"user-agent":      true,
+	"x-amzn-trace-id": true,
+	"expect":          true,
+}
+
+// V4 SigV4签名
+type V4 struct {
+	Credentials s3.CredentialsProvider
+	Region      string
+	Service     string
+	// DisablePathNormalization 不规范化路径中的 . 、.. 及重复的 / ,S3需设置为true
+	DisablePathNormalization bool
+	// ContentSHA256Header 添加x-amz-content-sha256头,S3需设置为true
+	ContentSHA256Header bool
+}
+
+// NewV4 创建SigV4签名,service为s3时按S3的规则签名
+func NewV4(credentials s3.CredentialsProvider, region, service string) *V4 {
+	return &V4{
+		Credentials:              credentials,
+		Region:                   region,
+		Service:                  service,
+		DisablePathNormalization: service == "s3",
+		ContentSHA256Header:      service == "s3",
+	}
+}
+
+// Sign 对请求签名,设置X-Amz-Date、Authorization等请求头
+// payloadHash为请求体的sha256十六进制值或UnsignedPayload,为空时读取请求体计算
+func (s *V4) Sign(ctx context.Context, req *http.Request, payloadHash string, signTime time.Time) error {
+	creds, err := s.Credentials.Retrieve(ctx)
+	if err != nil {
+		return err
+	}
+	if payloadHash == "" {
+		if payloadHash, err = bodySHA256(req); err != nil {
+			return err
+		}
+	}
+	signTime = signTime.UTC()
+	req.Header.Del("Authorization")
+	req.Header.Set("X-Amz-Date", signTime.Format(v4DateTimeFormat))
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	if s.ContentSHA256Header {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+	canonRequest, signedHeaders := s.canonicalRequest(req, CanonicalQuery(req.URL.Query()), payloadHash)
+	signature := s.signature(creds.SecretAccessKey, s.stringToSign(canonRequest, signTime), signTime)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		v4Algorithm, creds.AccessKeyID, s.scope(signTime), signedHeaders, signature))
+	return nil
+}
+
+// Presign 生成预签名URL,请求头中除Authorization外的头都参与签名,使用URL时必须携带相同的头
+func (s *V4) Presign(ctx context.Context, req *http.Request, expires time.Duration, signTime time.Time) (string, error) {
+	creds, err := s.Credentials.Retrieve(ctx)
+	if err != nil {
+		return "", err
+	}
+	signTime = signTime.UTC()
+	_, signedHeaders := s.canonicalHeaders(req)
+	query := req.URL.Query()
+	query.Set("X-Amz-Algorithm", v4Algorithm)
+	query.Set("X-Amz-Credential", creds.AccessKeyID+"/"+s.scope(signTime))
+	query.Set("X-Amz-Date", signTime.Format(v4DateTimeFormat))
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expires/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", signedHeaders)
+	if creds.SessionToken != "" {
+		query.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	canonQuery := CanonicalQuery(query)
+	canonRequest, _ := s.canonicalRequest(req, canonQuery, UnsignedPayload)
+	signature := s.signature(creds.SecretAccessKey, s.stringToSign(canonRequest, signTime), signTime)
+	u := *req.URL
+	u.RawQuery = canonQuery + "&X-Amz-Signature=" + signature
+	return u.String(), nil
+}
+
+func (s *V4) scope(signTime time.Time) string {
+	return strings.Join([]string{signTime.Format(v4DateFormat), s.Region, s.Service, v4Request}, "/")
+}
+
+// canonicalRequest 规范化请求,返回规范化的请求及参与签名的请求头名称
+func (s *V4) canonicalRequest(req *http.Request, canonQuery, payloadHash string) (string, string) {
+	canonHeaders, signedHeaders := s.canonicalHeaders(req)
+	return strings.Join([]string{
+		req.Method,
+		s.canonicalURI(req.URL),
+		canonQuery,
+		canonHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n"), signedHeaders
+}
+
+func (s *V4) stringToSign(canonRequest string, signTime time.Time) string {
+	hash := sha256.Sum256([]byte(canonRequest))
+	return strings.Join([]string{
+		v4Algorithm,
+		signTime.Format(v4DateTimeFormat),
+		s.scope(signTime),
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+}
+
+func (s *V4) signature(secret, strToSign string, signTime time.Time) string {
+	kDate := hmacSHA256([]byte("AWS4"+secret), []byte(signTime.Format(v4DateFormat)))
+	kRegion := hmacSHA256(kDate, []byte(s.Region))
+	kService := hmacSHA256(kRegion, []byte(s.Service))
+	kSigning := hmacSHA256(kService, []byte(v4Request))
+	return hex.EncodeToString(hmacSHA256(kSigning, []byte(strToSign)))
+}
+
+// canonicalHeaders 规范化请求头,返回规范化的请求头及参与签名的请求头名称
+func (s *V4) canonicalHeaders(req *http.Request) (string, string) {
+	values := map[string][]string{}
+	for k, v := range req.Header {
+		key := strings.ToLower(k)
+		if v4IgnoredHeaders[key] {
+			continue
+		}
+		values[key] = append(values[key], v...)
+	}
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	values["host"] = []string{host}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var canonHeaders strings.Builder
+	for _, k := range keys {
+		trimmed := make([]string, len(values[k]))
+		for i, v := range values[k] {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		canonHeaders.WriteString(k + ":" + strings.Join(trimmed, ",") + "\n")
+	}
+	return canonHeaders.String(), strings.Join(keys, ";")
+}
+
+// canonicalURI 按RFC 3986编码路径,除S3外先规范化路径
+func (s *V4) canonicalURI(u *url.URL) string {
+	p := u.Path
+	if p == "" {
+		p = "/"
+	}
+	if !s.DisablePathNormalization {
+		p = normalizePath(p)
+	}
+	return EscapePath(p)
+}
+
+// normalizePath 去除路径中的 . 、.. 及重复的 / ,保留结尾的 /
+func normalizePath(p string) string {
+	var segments []string
+	for _, segment := range strings.Split(p, "/") {
+		switch segment {
+		case "", ".":
+		case "..":
+			if len(segments) > 0 {
+				segments = segments[:len(segments)-1]
+			}
+		default:
+			segments = append(segments, segment)
+		}
+	}
+	normalized := "/" + strings.Join(segments, "/")
+	if strings.HasSuffix(p, "/") && normalized != "/" {
+		normalized += "/"
+	}
+	return normalized
+}
+
+func hmacSHA256(key []byte, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write(data)
+	return h.Sum(nil)
+}